@@ -14,6 +14,101 @@ func VehicalNotParkingHereError() error {
 	return errors.New("Sorry, vehical not parking here")
 }
 
+// VehicalAlreadyParkingError is error vehical with same plate no is already parking
+func VehicalAlreadyParkingError() error {
+	return errors.New("Sorry, vehical already parking")
+}
+
+// VehicalPlateNoRequiredError is error lot is shared and need plate no to leave
+func VehicalPlateNoRequiredError() error {
+	return errors.New("Sorry, more than one vehical parking here, please input registration number")
+}
+
+// ParkingLotNotAvailableError is error parking lot can not be reserved
+func ParkingLotNotAvailableError() error {
+	return errors.New("Sorry, parking lot is not available")
+}
+
+// ParkingLotNotReservedError is error parking lot was not reserved
+func ParkingLotNotReservedError() error {
+	return errors.New("Sorry, parking lot is not reserved")
+}
+
+// VehicleTypeInvalidError is error vehicle type is not support
+func VehicleTypeInvalidError() error {
+	return errors.New("Sorry, vehicle type is invalid")
+}
+
+// VehiclePermitRequiredError is error accessible vehicle has no permit
+func VehiclePermitRequiredError() error {
+	return errors.New("Sorry, accessible vehicle require permit number")
+}
+
+// LotCategoryInvalidError is error lot category is not support
+func LotCategoryInvalidError() error {
+	return errors.New("Sorry, parking lot category is invalid")
+}
+
+// LotCategoryRangeInvalidError is error lot category range is out of created lots
+func LotCategoryRangeInvalidError() error {
+	return errors.New("Sorry, parking lot category range is invalid")
+}
+
+// LevelInvalidError is error level is not exist
+func LevelInvalidError() error {
+	return errors.New("Sorry, parking level is invalid")
+}
+
+// LotNoInvalidError is error lot no or lot label is not exist
+func LotNoInvalidError() error {
+	return errors.New("Sorry, parking lot number is invalid")
+}
+
+// ParkingSiteNotFoundError is error named parking lot is not exist
+func ParkingSiteNotFoundError() error {
+	return errors.New("Sorry, parking lot name not found")
+}
+
+// ParkingSiteAlreadyExistError is error named parking lot is already exist
+func ParkingSiteAlreadyExistError() error {
+	return errors.New("Sorry, parking lot name already exists")
+}
+
+// ParkingSiteNotEmptyError is error named parking lot still has vehicles
+func ParkingSiteNotEmptyError() error {
+	return errors.New("Sorry, parking lot still has vehicles parking")
+}
+
+// TariffConfigInvalidError is error tariff config has invalid rate
+func TariffConfigInvalidError() error {
+	return errors.New("Sorry, tariff config is invalid")
+}
+
+// TicketInvalidError is error ticket id is not issued
+func TicketInvalidError() error {
+	return errors.New("Sorry, ticket is invalid")
+}
+
+// TicketAlreadyUsedError is error vehicle of ticket already leave
+func TicketAlreadyUsedError() error {
+	return errors.New("Sorry, ticket was already used")
+}
+
+// AllocationStrategyInvalidError is error allocation strategy is not supported
+func AllocationStrategyInvalidError() error {
+	return errors.New("Allocation strategy is invalid")
+}
+
+// SnapshotInvalidError is error snapshot file has invalid parking state
+func SnapshotInvalidError() error {
+	return errors.New("Sorry, snapshot is invalid")
+}
+
+// ReportRangeInvalidError is error report to time is not after from time or range is too long
+func ReportRangeInvalidError() error {
+	return errors.New("Report range is invalid, to should be after from and within 366 days")
+}
+
 // InternalServerError some internal error
 func InternalServerError() error {
 	return errors.New("Internal server error")
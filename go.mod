@@ -1,3 +1,5 @@
 module parkinglot
 
 go 1.14
+
+require github.com/mattn/go-sqlite3 v1.14.6
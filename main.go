@@ -4,16 +4,60 @@ import (
 	"os"
 	"parkinglot/models"
 	"parkinglot/services"
+	"strings"
+)
+
+const (
+	// snapshotOption is option for file that keep parking lots between runs
+	snapshotOption = "--snapshot="
+	// journalOption is option for file that keep commands that change parking lots
+	journalOption = "--journal="
+	// storageOption is option for storage of parking lots (memory, sqlite:${file} or file:${dir})
+	storageOption = "--storage="
+	// outputOption is option for format of command responses (text, json or csv)
+	outputOption = "--output="
 )
 
 func main() {
 	// Input command type
-	// 1. File type
-	// 2. Standard input type
+	// 1. Serve type (serve ${address}) or TCP type (tcp ${address})
+	// 2. File type
+	// 3. Standard input type
+	// Option --snapshot=${file} restore parking lots when start and save them when exit
+	// Option --journal=${file} write commands before run and replay them on top of snapshot when start (not with serve type)
+	// Option --storage=sqlite:${file} keep parking lots in SQLite database instead of memory
+	// Option --storage=file:${dir} keep each parking lot in JSON file of directory instead of memory
+	// Option --output=json or --output=csv print each command response with status and error code
 	cmd := services.NewCommandInput()
-	if args := os.Args[1:]; len(args) > 0 {
+	args := []string{}
+	for _, arg := range os.Args[1:] {
+		if strings.HasPrefix(arg, snapshotOption) {
+			cmd.SnapshotFile(strings.TrimPrefix(arg, snapshotOption))
+			continue
+		}
+		if strings.HasPrefix(arg, journalOption) {
+			cmd.JournalFile(strings.TrimPrefix(arg, journalOption))
+			continue
+		}
+		if strings.HasPrefix(arg, storageOption) {
+			cmd.Storage(strings.TrimPrefix(arg, storageOption))
+			continue
+		}
+		if strings.HasPrefix(arg, outputOption) {
+			cmd.Output(strings.TrimPrefix(arg, outputOption))
+			continue
+		}
+		args = append(args, arg)
+	}
+	switch {
+	case len(args) > 0 && (args[0] == string(models.ServeType) || args[0] == string(models.TCPType)):
+		cmd.Type(models.CommandInputTypes(args[0]))
+		if len(args) > 1 {
+			cmd.Address(args[1])
+		}
+	case len(args) > 0:
 		cmd.Type(models.FileType).FileName(args[0])
-	} else {
+	default:
 		cmd.Type(models.InputType)
 	}
 
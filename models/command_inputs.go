@@ -6,13 +6,28 @@ type ParkingLotCommandInputs string
 // CommandInputTypes is command input type
 type CommandInputTypes string
 
+// OutputFormats is format of command responses
+type OutputFormats string
+
 const (
 	// CreateParkingLot use for create parking lot
 	CreateParkingLot ParkingLotCommandInputs = "create_parking_lot"
+	// CreateLevel use for create parking level with its own lots
+	CreateLevel ParkingLotCommandInputs = "create_level"
+	// SetLevelPriority use for set order of levels that find available lot
+	SetLevelPriority ParkingLotCommandInputs = "level_priority"
+	// SetLotPreference use for set lot categories order that vehicle type can use
+	SetLotPreference ParkingLotCommandInputs = "slot_preference"
+	// SetAllocationStrategy use for set way to choose lot for vehicle
+	SetAllocationStrategy ParkingLotCommandInputs = "allocation_strategy"
 	// ParkInLot use for car that need to park
 	ParkInLot ParkingLotCommandInputs = "park"
 	// LeaveFromLot use for car leave from park
 	LeaveFromLot ParkingLotCommandInputs = "leave"
+	// LeaveByTicket use for car leave from park by ticket id
+	LeaveByTicket ParkingLotCommandInputs = "leave_by_ticket"
+	// LeaveByPlateNo use for car leave from park by registration number
+	LeaveByPlateNo ParkingLotCommandInputs = "leave_by_registration"
 	// GetBusyParkingStatus use for get list parking lot that have busy status
 	GetBusyParkingStatus ParkingLotCommandInputs = "status"
 	// GetPlateNoByCarColor use for get list plate number that parking in parking lot by car color
@@ -21,6 +36,38 @@ const (
 	GetLotNoByCarColor ParkingLotCommandInputs = "slot_numbers_for_cars_with_colour"
 	// GetLotNoByPlateNo use for get list lot no that parking in parking lot by plate no
 	GetLotNoByPlateNo ParkingLotCommandInputs = "slot_number_for_registration_number"
+	// GetPlateNoByVehicleType use for get list plate number that parking in parking lot by vehicle type
+	GetPlateNoByVehicleType ParkingLotCommandInputs = "registration_numbers_for_vehicle_type"
+	// GetLotNoByVehicleType use for get list lot no that parking in parking lot by vehicle type
+	GetLotNoByVehicleType ParkingLotCommandInputs = "slot_numbers_for_vehicle_type"
+	// LoadTariff use for load tariff config file that calculate fee when leave
+	LoadTariff ParkingLotCommandInputs = "load_tariff"
+	// ReserveLot use for hold parking lot for specific registration number
+	ReserveLot ParkingLotCommandInputs = "reserve"
+	// UnreserveLot use for release parking lot that was reserved
+	UnreserveLot ParkingLotCommandInputs = "unreserve"
+	// GetHistoryByPlateNo use for get visits of registration number including vehicles that left
+	GetHistoryByPlateNo ParkingLotCommandInputs = "history"
+	// GetHistoryByLotNo use for get visits that use parking lot number
+	GetHistoryByLotNo ParkingLotCommandInputs = "history_slot"
+	// GetHistoryBetween use for get visits that vehicle was in parking lot between two times
+	GetHistoryBetween ParkingLotCommandInputs = "history_between"
+	// GetReport use for get occupancy, peak hours, dwell time, turnover and busiest colours and vehicle types
+	GetReport ParkingLotCommandInputs = "report"
+	// CreateParkingSite use for create new named parking lot and select it
+	CreateParkingSite ParkingLotCommandInputs = "create_lot"
+	// UseParkingSite use for select named parking lot that other commands operate on
+	UseParkingSite ParkingLotCommandInputs = "use"
+	// GetParkingSites use for get list named parking lots
+	GetParkingSites ParkingLotCommandInputs = "lots"
+	// DropParkingSite use for remove named parking lot that has no vehicle
+	DropParkingSite ParkingLotCommandInputs = "drop_lot"
+	// GetParkingSiteByPlateNo use for get named parking lots that plate no is parking
+	GetParkingSiteByPlateNo ParkingLotCommandInputs = "lot_for_registration_number"
+	// SaveSnapshot use for save all named parking lots to file
+	SaveSnapshot ParkingLotCommandInputs = "save"
+	// LoadSnapshot use for restore all named parking lots from file
+	LoadSnapshot ParkingLotCommandInputs = "load"
 	// Exit use for exit from program
 	Exit ParkingLotCommandInputs = "exit"
 )
@@ -30,4 +77,17 @@ const (
 	FileType CommandInputTypes = "file"
 	// InputType is input command type way
 	InputType CommandInputTypes = "input"
+	// ServeType is HTTP REST API way
+	ServeType CommandInputTypes = "serve"
+	// TCPType is line protocol TCP server way that use same commands as input
+	TCPType CommandInputTypes = "tcp"
+)
+
+const (
+	// TextOutput is English sentence and table responses
+	TextOutput OutputFormats = "text"
+	// JSONOutput is one JSON object of each command response
+	JSONOutput OutputFormats = "json"
+	// CSVOutput is one CSV record of each command response
+	CSVOutput OutputFormats = "csv"
 )
@@ -11,3 +11,55 @@ const (
 	// Reserve is availble but reserve specific car
 	Reserve ParkingStatus = "reserve"
 )
+
+// LotCategory type of parking lot category
+type LotCategory string
+
+const (
+	// CompactLot is normal lot for car
+	CompactLot LotCategory = "compact"
+	// LargeLot is lot for big vehicle
+	LargeLot LotCategory = "large"
+	// ChargingLot is lot that has EV charger
+	ChargingLot LotCategory = "ev_charging"
+	// DisabledLot is lot for vehicle that has accessible permit
+	DisabledLot LotCategory = "disabled"
+	// MotorcycleLot is lot for motorcycle
+	MotorcycleLot LotCategory = "motorcycle"
+)
+
+// AllocationStrategy type of way to choose lot for vehicle
+type AllocationStrategy string
+
+const (
+	// NearestStrategy choose lot nearest the entrance
+	NearestStrategy AllocationStrategy = "nearest"
+	// FarthestStrategy choose lot farthest from the entrance
+	FarthestStrategy AllocationStrategy = "farthest"
+	// RoundRobinStrategy choose next lot after last chosen lot
+	RoundRobinStrategy AllocationStrategy = "round_robin"
+	// BalancedStrategy choose lot in level that has fewest busy lots
+	BalancedStrategy AllocationStrategy = "balanced"
+	// ClosestExitStrategy choose lot closest to exit lot
+	ClosestExitStrategy AllocationStrategy = "closest_exit"
+	// RandomStrategy choose random lot by seed
+	RandomStrategy AllocationStrategy = "random"
+)
+
+// ParkingEventType type of change that happened in parking
+type ParkingEventType string
+
+const (
+	// VehicleParkedEvent is vehicle parked
+	VehicleParkedEvent ParkingEventType = "park"
+	// VehicleLeftEvent is vehicle left
+	VehicleLeftEvent ParkingEventType = "leave"
+	// LotReservedEvent is lot reserved for vehicle
+	LotReservedEvent ParkingEventType = "reserve"
+	// LotUnreservedEvent is reserved lot released
+	LotUnreservedEvent ParkingEventType = "unreserve"
+	// LotCreatedEvent is lots created
+	LotCreatedEvent ParkingEventType = "lot_created"
+	// ParkRejectedFullEvent is vehicle can not park because no lot available for it
+	ParkRejectedFullEvent ParkingEventType = "park_rejected_full"
+)
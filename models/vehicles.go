@@ -0,0 +1,19 @@
+package models
+
+// VehicleType type of vehicle
+type VehicleType string
+
+const (
+	// Motorcycle is small vehicle that can share lot
+	Motorcycle VehicleType = "motorcycle"
+	// Car is vehicle that use one lot
+	Car VehicleType = "car"
+	// Truck is big vehicle that use adjacent lots
+	Truck VehicleType = "truck"
+	// Bus is biggest vehicle that use adjacent lots
+	Bus VehicleType = "bus"
+	// ElectricVehicle is car that need charging
+	ElectricVehicle VehicleType = "ev"
+	// Accessible is car that has accessible permit
+	Accessible VehicleType = "accessible"
+)
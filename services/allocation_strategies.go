@@ -0,0 +1,246 @@
+package services
+
+import (
+	"math/rand"
+	"parkinglot/errmsgs"
+	"parkinglot/models"
+	"sort"
+)
+
+// IAllocationStrategy is way to choose lots for vehicle
+type IAllocationStrategy interface {
+	Name() models.AllocationStrategy
+	// LevelOrder is order of levels to find lots, levelNos is order by level priority
+	LevelOrder(parking *Parking, levelNos []int) []int
+	// Choose is choose one run of lots from candidate runs that sorted by lot no
+	Choose(candidates [][]*ParkingLot) []*ParkingLot
+}
+
+// StrategyAttributes is optional attributes for build allocation strategy
+type StrategyAttributes struct {
+	// ExitLotNo is lot no that nearest the exit for closest exit strategy
+	ExitLotNo int
+	// Seed is seed of random strategy
+	Seed int64
+}
+
+// NewAllocationStrategy is a new allocation strategy by strategy name
+func NewAllocationStrategy(name models.AllocationStrategy, attrs StrategyAttributes) (IAllocationStrategy, error) {
+	switch name {
+	case models.NearestStrategy:
+		return &nearestStrategy{}, nil
+	case models.FarthestStrategy:
+		return &farthestStrategy{}, nil
+	case models.RoundRobinStrategy:
+		return &roundRobinStrategy{}, nil
+	case models.BalancedStrategy:
+		return &balancedStrategy{}, nil
+	case models.ClosestExitStrategy:
+		if attrs.ExitLotNo <= 0 {
+			return nil, errmsgs.LotNoInvalidError()
+		}
+		return &closestExitStrategy{exitLotNo: attrs.ExitLotNo}, nil
+	case models.RandomStrategy:
+		return newRandomStrategy(attrs.Seed), nil
+	default:
+		return nil, errmsgs.AllocationStrategyInvalidError()
+	}
+}
+
+// nearestStrategy is choose lot nearest the entrance, it is default strategy
+type nearestStrategy struct{}
+
+func (strategy *nearestStrategy) Name() models.AllocationStrategy {
+	return models.NearestStrategy
+}
+
+func (strategy *nearestStrategy) LevelOrder(parking *Parking, levelNos []int) []int {
+	return levelNos
+}
+
+func (strategy *nearestStrategy) Choose(candidates [][]*ParkingLot) []*ParkingLot {
+	if len(candidates) == 0 {
+		return nil
+	}
+	return candidates[0]
+}
+
+// farthestStrategy is choose lot farthest from the entrance for spread wear
+type farthestStrategy struct{}
+
+func (strategy *farthestStrategy) Name() models.AllocationStrategy {
+	return models.FarthestStrategy
+}
+
+func (strategy *farthestStrategy) LevelOrder(parking *Parking, levelNos []int) []int {
+	return levelNos
+}
+
+func (strategy *farthestStrategy) Choose(candidates [][]*ParkingLot) []*ParkingLot {
+	if len(candidates) == 0 {
+		return nil
+	}
+	return candidates[len(candidates)-1]
+}
+
+// roundRobinStrategy is choose next lot after last chosen lot and start over at the end
+type roundRobinStrategy struct {
+	lastLotNo int
+}
+
+func (strategy *roundRobinStrategy) Name() models.AllocationStrategy {
+	return models.RoundRobinStrategy
+}
+
+func (strategy *roundRobinStrategy) LevelOrder(parking *Parking, levelNos []int) []int {
+	return levelNos
+}
+
+func (strategy *roundRobinStrategy) Choose(candidates [][]*ParkingLot) []*ParkingLot {
+	if len(candidates) == 0 {
+		return nil
+	}
+	chosen := candidates[0]
+	for _, candidate := range candidates {
+		if candidate[0].lotNo > strategy.lastLotNo {
+			chosen = candidate
+			break
+		}
+	}
+	strategy.lastLotNo = chosen[0].lotNo
+	return chosen
+}
+
+// balancedStrategy is choose nearest lot in level that has fewest busy lots
+type balancedStrategy struct{}
+
+func (strategy *balancedStrategy) Name() models.AllocationStrategy {
+	return models.BalancedStrategy
+}
+
+func (strategy *balancedStrategy) LevelOrder(parking *Parking, levelNos []int) []int {
+	busyLots := map[int]int{}
+	for _, parkingLot := range parking.parkingLotKeyValue {
+		if parkingLot.status != models.Available {
+			busyLots[parkingLot.levelNo]++
+		}
+	}
+
+	// Any level is split to each level for compare busy lots
+	balancedLevelNos := []int{}
+	for _, levelNo := range levelNos {
+		if levelNo != anyLevelNo {
+			balancedLevelNos = append(balancedLevelNos, levelNo)
+			continue
+		}
+		for levelNo := 0; levelNo <= len(parking.levels); levelNo++ {
+			balancedLevelNos = append(balancedLevelNos, levelNo)
+		}
+	}
+	sort.SliceStable(balancedLevelNos, func(i, j int) bool {
+		return busyLots[balancedLevelNos[i]] < busyLots[balancedLevelNos[j]]
+	})
+	return balancedLevelNos
+}
+
+func (strategy *balancedStrategy) Choose(candidates [][]*ParkingLot) []*ParkingLot {
+	if len(candidates) == 0 {
+		return nil
+	}
+	return candidates[0]
+}
+
+// closestExitStrategy is choose lot that closest to exit lot
+type closestExitStrategy struct {
+	exitLotNo int
+}
+
+func (strategy *closestExitStrategy) Name() models.AllocationStrategy {
+	return models.ClosestExitStrategy
+}
+
+func (strategy *closestExitStrategy) LevelOrder(parking *Parking, levelNos []int) []int {
+	return levelNos
+}
+
+func (strategy *closestExitStrategy) Choose(candidates [][]*ParkingLot) []*ParkingLot {
+	var (
+		chosen      []*ParkingLot
+		minDistance int
+	)
+	for _, candidate := range candidates {
+		distance := strategy.distance(candidate)
+		if chosen == nil || distance < minDistance {
+			chosen = candidate
+			minDistance = distance
+		}
+	}
+	return chosen
+}
+
+// distance is distance from exit lot to nearest lot of run
+func (strategy *closestExitStrategy) distance(lots []*ParkingLot) int {
+	firstLotNo := lots[0].lotNo
+	lastLotNo := lots[len(lots)-1].lotNo
+	switch {
+	case strategy.exitLotNo < firstLotNo:
+		return firstLotNo - strategy.exitLotNo
+	case strategy.exitLotNo > lastLotNo:
+		return strategy.exitLotNo - lastLotNo
+	default:
+		return 0
+	}
+}
+
+// randomStrategy is choose random lot by seed, same seed give same lots
+type randomStrategy struct {
+	// seed and draws of source are kept for save strategy in snapshot
+	seed   int64
+	source *countingSource
+	random *rand.Rand
+}
+
+// countingSource is random source that count numbers it gave
+// Source at the same position is made again by seed and skip the same amount of numbers
+type countingSource struct {
+	source rand.Source
+	draws  int64
+}
+
+func (source *countingSource) Int63() int64 {
+	source.draws++
+	return source.source.Int63()
+}
+
+func (source *countingSource) Seed(seed int64) {
+	source.source.Seed(seed)
+	source.draws = 0
+}
+
+// newRandomStrategy is local function for random strategy at start of seed
+func newRandomStrategy(seed int64) *randomStrategy {
+	source := &countingSource{source: rand.NewSource(seed)}
+	return &randomStrategy{seed: seed, source: source, random: rand.New(source)}
+}
+
+// skip is move source to position after draws numbers
+func (strategy *randomStrategy) skip(draws int64) {
+	for strategy.source.draws < draws {
+		strategy.source.Int63()
+	}
+}
+
+func (strategy *randomStrategy) Name() models.AllocationStrategy {
+	return models.RandomStrategy
+}
+
+func (strategy *randomStrategy) LevelOrder(parking *Parking, levelNos []int) []int {
+	return levelNos
+}
+
+func (strategy *randomStrategy) Choose(candidates [][]*ParkingLot) []*ParkingLot {
+	if len(candidates) == 0 {
+		return nil
+	}
+	return candidates[strategy.random.Intn(len(candidates))]
+}
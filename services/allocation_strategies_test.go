@@ -0,0 +1,146 @@
+package services
+
+import (
+	"fmt"
+	"parkinglot/errmsgs"
+	"parkinglot/models"
+	"testing"
+)
+
+// testStrategyParkHelper is park cars with strategy and return first lot no of each car
+func testStrategyParkHelper(parking IParking, strategy IAllocationStrategy, n int, t *testing.T) []int {
+	parking.SetAllocationStrategy(strategy)
+	lotNos := []int{}
+	for i := 1; i <= n; i++ {
+		parkLot, err := parking.Park(NewCar(fmt.Sprintf("plate-%d", i), "red", 1))
+		if err != nil || parkLot == nil {
+			t.Fatalf("Vehicle should be parking")
+		}
+		lotNos = append(lotNos, parkLot.lotNo)
+	}
+	return lotNos
+}
+
+func TestNewAllocationStrategyWithInputError(t *testing.T) {
+	strategy, err := NewAllocationStrategy(models.AllocationStrategy("shortest"), StrategyAttributes{})
+	if strategy != nil {
+		t.Errorf("Strategy should be empty")
+	}
+	if !(err != nil && err.Error() == errmsgs.AllocationStrategyInvalidError().Error()) {
+		t.Errorf("Error should have and should be allocation strategy is invalid")
+	}
+
+	strategy, err = NewAllocationStrategy(models.ClosestExitStrategy, StrategyAttributes{})
+	if strategy != nil {
+		t.Errorf("Strategy should be empty")
+	}
+	if !(err != nil && err.Error() == errmsgs.LotNoInvalidError().Error()) {
+		t.Errorf("Error should have and should be lot no is invalid")
+	}
+}
+
+func TestNearestStrategyIsDefault(t *testing.T) {
+	parking := NewParking("unit-testing")
+	parking.CreateParkingLot(5)
+
+	if parking.AllocationStrategy().Name() != models.NearestStrategy {
+		t.Errorf("Default strategy should be nearest")
+	}
+
+	strategy, _ := NewAllocationStrategy(models.NearestStrategy, StrategyAttributes{})
+	lotNos := testStrategyParkHelper(parking, strategy, 3, t)
+	if fmt.Sprint(lotNos) != "[1 2 3]" {
+		t.Errorf("Lot nos should be [1 2 3] not %v", lotNos)
+	}
+}
+
+func TestFarthestStrategyWithSuccess(t *testing.T) {
+	parking := NewParking("unit-testing")
+	parking.CreateParkingLot(5)
+
+	strategy, _ := NewAllocationStrategy(models.FarthestStrategy, StrategyAttributes{})
+	lotNos := testStrategyParkHelper(parking, strategy, 3, t)
+	if fmt.Sprint(lotNos) != "[5 4 3]" {
+		t.Errorf("Lot nos should be [5 4 3] not %v", lotNos)
+	}
+
+	truck, _ := NewVehicle(models.Truck, "truck-1", "red", VehicleAttributes{})
+	if _, err := parking.Park(truck); err != nil {
+		t.Errorf("Truck should be parking")
+	}
+	if lots := parking.GetParkingLotsWithPlateNo("truck-1"); len(lots) != 2 || lots[0].lotNo != 1 {
+		t.Errorf("Truck should be parking at 1-2")
+	}
+}
+
+func TestRoundRobinStrategyWithSuccess(t *testing.T) {
+	parking := NewParking("unit-testing")
+	parking.CreateParkingLot(4)
+
+	strategy, _ := NewAllocationStrategy(models.RoundRobinStrategy, StrategyAttributes{})
+	testStrategyParkHelper(parking, strategy, 2, t)
+	parking.Leave(1)
+
+	// Next lot is after last chosen lot even nearer lot is available
+	parkLot, _ := parking.Park(NewCar("plate-3", "red", 1))
+	if parkLot == nil || parkLot.lotNo != 3 {
+		t.Errorf("Parking lot no should be 3")
+	}
+	parkLot, _ = parking.Park(NewCar("plate-4", "red", 1))
+	if parkLot == nil || parkLot.lotNo != 4 {
+		t.Errorf("Parking lot no should be 4")
+	}
+	// Start over at the end
+	parkLot, _ = parking.Park(NewCar("plate-5", "red", 1))
+	if parkLot == nil || parkLot.lotNo != 1 {
+		t.Errorf("Parking lot no should be 1")
+	}
+}
+
+func TestBalancedStrategyWithSuccess(t *testing.T) {
+	parking := NewParking("unit-testing")
+	for i := 0; i < 3; i++ {
+		parking.CreateLevel(3)
+	}
+
+	strategy, _ := NewAllocationStrategy(models.BalancedStrategy, StrategyAttributes{})
+	lotNos := testStrategyParkHelper(parking, strategy, 4, t)
+	if fmt.Sprint(lotNos) != "[1 4 7 2]" {
+		t.Errorf("Lot nos should be [1 4 7 2] not %v", lotNos)
+	}
+}
+
+func TestClosestExitStrategyWithSuccess(t *testing.T) {
+	parking := NewParking("unit-testing")
+	parking.CreateParkingLot(10)
+
+	strategy, _ := NewAllocationStrategy(models.ClosestExitStrategy, StrategyAttributes{ExitLotNo: 7})
+	lotNos := testStrategyParkHelper(parking, strategy, 4, t)
+	if fmt.Sprint(lotNos) != "[7 6 8 5]" {
+		t.Errorf("Lot nos should be [7 6 8 5] not %v", lotNos)
+	}
+}
+
+func TestRandomStrategyWithSameSeed(t *testing.T) {
+	parkingLotNos := [][]int{}
+	for i := 0; i < 2; i++ {
+		parking := NewParking("unit-testing")
+		parking.CreateParkingLot(20)
+		strategy, _ := NewAllocationStrategy(models.RandomStrategy, StrategyAttributes{Seed: 42})
+		parkingLotNos = append(parkingLotNos, testStrategyParkHelper(parking, strategy, 10, t))
+	}
+
+	if fmt.Sprint(parkingLotNos[0]) != fmt.Sprint(parkingLotNos[1]) {
+		t.Errorf("Lot nos of same seed should be same")
+	}
+	if fmt.Sprint(parkingLotNos[0]) == "[1 2 3 4 5 6 7 8 9 10]" {
+		t.Errorf("Lot nos should not be nearest lots")
+	}
+	isUsed := map[int]bool{}
+	for _, lotNo := range parkingLotNos[0] {
+		if isUsed[lotNo] {
+			t.Errorf("Lot no %d should be used once", lotNo)
+		}
+		isUsed[lotNo] = true
+	}
+}
@@ -0,0 +1,22 @@
+package services
+
+import (
+	"time"
+)
+
+// IClock is time source of parking
+type IClock interface {
+	Now() time.Time
+}
+
+// systemClock is clock that use current system time
+type systemClock struct{}
+
+// NewSystemClock is a new clock that use current system time
+func NewSystemClock() IClock {
+	return &systemClock{}
+}
+
+func (svc *systemClock) Now() time.Time {
+	return time.Now()
+}
@@ -2,25 +2,92 @@ package services
 
 import (
 	"bufio"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"math"
 	"os"
+	"os/signal"
+	"parkinglot/errmsgs"
 	"parkinglot/models"
 	"strconv"
 	"strings"
+	"syscall"
+	"time"
 )
 
+const (
+	// optionPrefix is prefix of option attribute in command
+	optionPrefix = "--"
+	// defaultParkingSiteName is name of parking lot that selected when start
+	defaultParkingSiteName = "parking-lot"
+	// durationOption is leave option for show parking duration
+	durationOption = "--duration"
+	// sinceOption is status option for show entry time
+	sinceOption = "--since"
+	// ticketOption is park option for show ticket id
+	ticketOption = "--ticket"
+	// csvOption is report option for print report as CSV
+	csvOption = "--csv"
+	// jsonOption is report option for print report as JSON
+	jsonOption = "--json"
+	// memoryStorage is storage that keep parking lots only in memory, it is default storage
+	memoryStorage = "memory"
+	// sqliteStoragePrefix is prefix of storage that keep parking lots in SQLite database file
+	sqliteStoragePrefix = "sqlite:"
+	// fileStoragePrefix is prefix of storage that keep parking lots in JSON files of directory
+	fileStoragePrefix = "file:"
+)
+
+// fileCommands are commands that read or write files of server with path from command
+var fileCommands = map[models.ParkingLotCommandInputs]bool{
+	models.SaveSnapshot: true,
+	models.LoadSnapshot: true,
+	models.LoadTariff:   true,
+}
+
 // CommandInput is struct command input
 type CommandInput struct {
 	typ         models.CommandInputTypes
 	fileNameOpt string
+	addressOpt  string
+	// snapshotFileOpt is file that parking lots are restored from when start and saved to when exit
+	snapshotFileOpt string
+	// journalFileOpt is file that commands are written to before run and replayed from when start
+	journalFileOpt string
+	journal        *CommandJournal
+	// storageOpt is storage of parking lots, memory, sqlite:${file} or file:${dir}
+	storageOpt string
+	// outputOpt is format of command responses, empty is text
+	outputOpt models.OutputFormats
 }
 
 // ParkingLotCommandInput is struct parking lot command input
 type ParkingLotCommandInput struct {
 	reader io.Reader
+	// writer is output of command responses
+	writer io.Writer
 
-	parkingLotSvc IParking
+	parkingSitesSvc IParkingSites
+	// parkingLotSvc is selected parking lot, nil when no parking lot selected
+	// It is looked up by parkingLotName before each command because other connection may drop it or create it again
+	parkingLotSvc  IParking
+	parkingLotName string
+	// journal keep commands that change parking lots, nil is no journal
+	journal *CommandJournal
+	// isFileCommandDisabled is true for network connection that must not read or write files of server
+	isFileCommandDisabled bool
+	// loadedData is content of file that load command use instead of reading it when hasLoadedData is true
+	// It is set while journaled command run and while command is replayed from journal
+	loadedData    []byte
+	hasLoadedData bool
+
+	// output is format of command responses, response is structured response of running command
+	output             models.OutputFormats
+	response           *commandResponse
+	isCSVHeaderWritten bool
 }
 
 // NewCommandInput is new command input instance
@@ -28,10 +95,22 @@ func NewCommandInput() *CommandInput {
 	return &CommandInput{}
 }
 
-func newParkingLotCommandInput(reader io.Reader, parkingLotSvc IParking) *ParkingLotCommandInput {
-	return &ParkingLotCommandInput{
-		reader:        reader,
-		parkingLotSvc: parkingLotSvc,
+func newParkingLotCommandInput(reader io.Reader, writer io.Writer, parkingSitesSvc IParkingSites, parkingLotSvc IParking) *ParkingLotCommandInput {
+	parkingCommand := &ParkingLotCommandInput{
+		reader:          reader,
+		writer:          writer,
+		parkingSitesSvc: parkingSitesSvc,
+	}
+	parkingCommand.selectParkingLot(parkingLotSvc)
+	return parkingCommand
+}
+
+// selectParkingLot is local function for select parking lot, nil is no parking lot selected
+func (svc *ParkingLotCommandInput) selectParkingLot(parkingLotSvc IParking) {
+	svc.parkingLotSvc = parkingLotSvc
+	svc.parkingLotName = ""
+	if parkingLotSvc != nil {
+		svc.parkingLotName = parkingLotSvc.Name()
 	}
 }
 
@@ -40,6 +119,20 @@ func printf(topic string, params ...interface{}) {
 	fmt.Printf(fmt.Sprintf("%s\n", topic), params...)
 }
 
+// printf is print formatter to writer of command input
+// Structured response keep message instead, lines of message are joined with new line
+func (svc *ParkingLotCommandInput) printf(topic string, params ...interface{}) {
+	if svc.response != nil {
+		message := fmt.Sprintf(topic, params...)
+		if svc.response.Message != "" {
+			message = svc.response.Message + "\n" + message
+		}
+		svc.response.Message = message
+		return
+	}
+	fmt.Fprintf(svc.writer, fmt.Sprintf("%s\n", topic), params...)
+}
+
 // Type is command input type
 func (svc *CommandInput) Type(typ models.CommandInputTypes) *CommandInput {
 	svc.typ = typ
@@ -52,8 +145,60 @@ func (svc *CommandInput) FileName(name string) *CommandInput {
 	return svc
 }
 
+// Address is set address for serve type
+func (svc *CommandInput) Address(address string) *CommandInput {
+	svc.addressOpt = address
+	return svc
+}
+
+// SnapshotFile is set file that keep parking lots between runs
+func (svc *CommandInput) SnapshotFile(path string) *CommandInput {
+	svc.snapshotFileOpt = path
+	return svc
+}
+
+// JournalFile is set file that keep commands that change parking lots
+func (svc *CommandInput) JournalFile(path string) *CommandInput {
+	svc.journalFileOpt = path
+	return svc
+}
+
+// Output is set format of command responses (text, json or csv)
+func (svc *CommandInput) Output(format string) *CommandInput {
+	svc.outputOpt = models.OutputFormats(format)
+	return svc
+}
+
+// Storage is set storage of parking lots
+func (svc *CommandInput) Storage(storage string) *CommandInput {
+	svc.storageOpt = storage
+	return svc
+}
+
 // Run is command input type
 func (svc *CommandInput) Run() {
+	switch svc.outputOpt {
+	case "", models.TextOutput, models.JSONOutput, models.CSVOutput:
+	default:
+		printf("Output is invalid")
+		os.Exit(1)
+	}
+	// HTTP changes are not commands so they can not be written to journal
+	if svc.typ == models.ServeType && svc.journalFileOpt != "" {
+		printf("Journal can not be used with serve, use tcp or storage instead")
+		os.Exit(1)
+	}
+	// Journal is compacted into snapshot, it would grow without limit without snapshot
+	if svc.journalFileOpt != "" && svc.snapshotFileOpt == "" {
+		printf("Journal can not be used without snapshot")
+		os.Exit(1)
+	}
+
+	if svc.typ == models.ServeType || svc.typ == models.TCPType {
+		svc.serve()
+		return
+	}
+
 	var reader io.Reader
 	switch svc.typ {
 	case models.FileType:
@@ -68,13 +213,163 @@ func (svc *CommandInput) Run() {
 		reader = bufio.NewReader(os.Stdin)
 	}
 
-	parkingLotSvc := NewParking("parking-lot")
-	parkingCommand := newParkingLotCommandInput(reader, parkingLotSvc)
+	parkingSitesSvc := svc.newParkingSites()
+	parkingLotSvc, err := parkingSitesSvc.Get(defaultParkingSiteName)
+	if err != nil {
+		parkingLotSvc = nil
+	}
+	svc.saveOnSignal(parkingSitesSvc)
+	parkingCommand := newParkingLotCommandInput(reader, os.Stdout, parkingSitesSvc, parkingLotSvc)
+	parkingCommand.journal = svc.journal
+	parkingCommand.output = svc.outputOpt
 	parkingCommand.start()
+	svc.saveParkingSites(parkingSitesSvc)
+}
+
+// newParkingSites is parking sites from snapshot file when it exists, otherwise with default parking lot
+// Commands in journal file that are not in snapshot are replayed on top of it
+func (svc *CommandInput) newParkingSites() IParkingSites {
+	if svc.storageOpt != "" && svc.storageOpt != memoryStorage {
+		return svc.newStoredParkingSites()
+	}
+
+	clock := NewSystemClock()
+	if svc.journalFileOpt != "" {
+		journal, err := OpenCommandJournal(svc.journalFileOpt, svc.snapshotFileOpt, clock)
+		if err != nil {
+			printf(err.Error())
+			os.Exit(1)
+		}
+		svc.journal = journal
+		clock = journal.Clock()
+	}
+
+	parkingSitesSvc := NewParkingSitesWithClock(clock)
+	snapshot := ParkingSitesSnapshot{}
+	if _, err := os.Stat(svc.snapshotFileOpt); svc.snapshotFileOpt != "" && err == nil {
+		snapshot, err = loadSnapshotFile(svc.snapshotFileOpt)
+		if err == nil {
+			err = parkingSitesSvc.Restore(snapshot)
+		}
+		if err != nil {
+			printf(err.Error())
+			os.Exit(1)
+		}
+	} else if _, err := parkingSitesSvc.Create(defaultParkingSiteName); err != nil {
+		printf(err.Error())
+		os.Exit(1)
+	}
+
+	if svc.journal != nil {
+		svc.journal.Replay(parkingSitesSvc, snapshot.JournalSeqNo)
+	}
+	return parkingSitesSvc
+}
+
+// newStoredParkingSites is parking sites in storage, default parking lot is created when storage is empty
+// Storage keep every change so snapshot and journal are not used with it
+func (svc *CommandInput) newStoredParkingSites() IParkingSites {
+	if svc.snapshotFileOpt != "" || svc.journalFileOpt != "" {
+		printf("Storage can not be used with snapshot or journal")
+		os.Exit(1)
+	}
+
+	var (
+		storage IParkingStorage
+		err     error
+	)
+	switch {
+	case strings.HasPrefix(svc.storageOpt, sqliteStoragePrefix):
+		storage, err = OpenSQLiteStorage(strings.TrimPrefix(svc.storageOpt, sqliteStoragePrefix))
+	case strings.HasPrefix(svc.storageOpt, fileStoragePrefix):
+		storage, err = OpenFileStorage(strings.TrimPrefix(svc.storageOpt, fileStoragePrefix))
+	default:
+		printf("Storage is invalid")
+		os.Exit(1)
+	}
+	if err != nil {
+		printf(err.Error())
+		os.Exit(1)
+	}
+	parkingSitesSvc, err := NewParkingSitesWithStorage(storage, NewSystemClock())
+	if err != nil {
+		printf(err.Error())
+		os.Exit(1)
+	}
+	if len(parkingSitesSvc.Parkings()) == 0 {
+		if _, err := parkingSitesSvc.Create(defaultParkingSiteName); err != nil {
+			printf(err.Error())
+			os.Exit(1)
+		}
+	}
+	return parkingSitesSvc
+}
+
+// saveParkingSites is save parking sites to snapshot file when it is set
+// Journal is compacted into snapshot when journal file is set
+func (svc *CommandInput) saveParkingSites(parkingSitesSvc IParkingSites) {
+	if svc.snapshotFileOpt == "" {
+		return
+	}
+
+	var err error
+	if svc.journal != nil {
+		err = svc.journal.Compact(parkingSitesSvc)
+	} else {
+		err = SaveSnapshot(parkingSitesSvc, svc.snapshotFileOpt)
+	}
+	if err != nil {
+		printf(err.Error())
+	}
+}
+
+// saveOnSignal is save parking sites to snapshot file then exit when process is interrupted or terminated
+func (svc *CommandInput) saveOnSignal(parkingSitesSvc IParkingSites) {
+	if svc.snapshotFileOpt == "" {
+		return
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		svc.saveParkingSites(parkingSitesSvc)
+		os.Exit(0)
+	}()
+}
+
+// serve is serve HTTP REST API or TCP commands with default parking lot
+func (svc *CommandInput) serve() {
+	parkingSitesSvc := svc.newParkingSites()
+	svc.saveOnSignal(parkingSitesSvc)
+
+	var err error
+	address := svc.addressOpt
+	switch svc.typ {
+	case models.TCPType:
+		if address == "" {
+			address = defaultTCPAddress
+		}
+		printf("Serving parking lot commands at %s", address)
+		tcpServer := NewTCPServer(parkingSitesSvc)
+		tcpServer.SetJournal(svc.journal)
+		tcpServer.SetOutput(svc.outputOpt)
+		err = tcpServer.ListenAndServe(address)
+	default:
+		if address == "" {
+			address = defaultServeAddress
+		}
+		printf("Serving parking lot API at %s", address)
+		err = NewHTTPServer(parkingSitesSvc).ListenAndServe(address)
+	}
+	if err != nil {
+		printf(err.Error())
+		os.Exit(1)
+	}
 }
 
 func (svc *ParkingLotCommandInput) start() {
-	if svc.reader == nil || svc.parkingLotSvc == nil {
+	if svc.reader == nil || svc.parkingSitesSvc == nil {
 		printf("Internal server error")
 		os.Exit(1)
 	}
@@ -82,11 +377,55 @@ func (svc *ParkingLotCommandInput) start() {
 	scanner := bufio.NewScanner(svc.reader)
 	for scanner.Scan() {
 		cmdStrs := strings.TrimSpace(scanner.Text())
+		// Exit command stop reading commands
+		if models.ParkingLotCommandInputs(strings.Split(cmdStrs, " ")[0]) == models.Exit {
+			return
+		}
 		svc.commands(cmdStrs)
 	}
 }
 
+// commands is run command, command that change parking lots is written to journal before run
+// Structured response is written after command run
 func (svc *ParkingLotCommandInput) commands(cmdStrs string) {
+	svc.beginResponse(cmdStrs)
+	defer svc.endResponse()
+
+	command := models.ParkingLotCommandInputs(strings.Split(cmdStrs, " ")[0])
+	// File command that is not allowed is rejected by runCommand without journal
+	if svc.journal == nil || !journaledCommands[command] || (svc.isFileCommandDisabled && fileCommands[command]) {
+		svc.runCommand(cmdStrs)
+		return
+	}
+
+	lotName := ""
+	if svc.parkingLotSvc != nil {
+		lotName = svc.parkingLotSvc.Name()
+	}
+	// Content of loaded file is written to journal because file may change before replay
+	var (
+		data    []byte
+		hasData bool
+	)
+	if attrs := strings.Split(cmdStrs, " ")[1:]; journaledFileCommands[command] && len(attrs) != 0 {
+		var err error
+		if data, err = svc.readFile(attrs[0]); err != nil {
+			svc.printError(err)
+			return
+		}
+		hasData = true
+	}
+	err := svc.journal.Run(svc.parkingSitesSvc, lotName, cmdStrs, data, hasData, func() {
+		svc.loadedData, svc.hasLoadedData = data, hasData
+		svc.runCommand(cmdStrs)
+		svc.loadedData, svc.hasLoadedData = nil, false
+	})
+	if err != nil {
+		svc.printError(err)
+	}
+}
+
+func (svc *ParkingLotCommandInput) runCommand(cmdStrs string) {
 	cmds := strings.Split(cmdStrs, " ")
 	if len(cmds) == 0 {
 		return
@@ -94,13 +433,71 @@ func (svc *ParkingLotCommandInput) commands(cmdStrs string) {
 	command := models.ParkingLotCommandInputs(cmds[0])
 	attributes := cmds[1:]
 
+	if svc.isFileCommandDisabled && fileCommands[command] {
+		svc.printFail(commandNotAllowedCode, "Command is not allowed over network connection")
+		return
+	}
+
+	// Selected parking lot that was dropped is not used, parking lot that is created again with its name is used
+	if svc.parkingLotName != "" {
+		parkingLotSvc, err := svc.parkingSitesSvc.Get(svc.parkingLotName)
+		if err != nil {
+			parkingLotSvc = nil
+		}
+		svc.parkingLotSvc = parkingLotSvc
+	}
+
+	// Commands for named parking lots do not need selected parking lot
+	switch command {
+	case models.CreateParkingSite:
+		svc.handleCreateParkingSite(attributes...)
+		return
+	case models.UseParkingSite:
+		svc.handleUseParkingSite(attributes...)
+		return
+	case models.GetParkingSites:
+		svc.handleGetParkingSites(attributes...)
+		return
+	case models.DropParkingSite:
+		svc.handleDropParkingSite(attributes...)
+		return
+	case models.GetParkingSiteByPlateNo:
+		svc.handleGetParkingSiteByPlateNo(attributes...)
+		return
+	case models.SaveSnapshot:
+		svc.handleSaveSnapshot(attributes...)
+		return
+	case models.LoadSnapshot:
+		svc.handleLoadSnapshot(attributes...)
+		return
+	case models.Exit:
+		return
+	}
+
+	if svc.parkingLotSvc == nil {
+		svc.printFail(lotNotSelectedCode, "Please select parking lot with use command")
+		return
+	}
+
 	switch command {
 	case models.CreateParkingLot:
 		svc.handleCreateParkingLot(attributes...)
+	case models.CreateLevel:
+		svc.handleCreateLevel(attributes...)
+	case models.SetLevelPriority:
+		svc.handleSetLevelPriority(attributes...)
+	case models.SetLotPreference:
+		svc.handleSetLotPreference(attributes...)
+	case models.SetAllocationStrategy:
+		svc.handleSetAllocationStrategy(attributes...)
 	case models.ParkInLot:
 		svc.handleParkInLot(attributes...)
 	case models.LeaveFromLot:
 		svc.handleLeaveFromLot(attributes...)
+	case models.LeaveByTicket:
+		svc.handleLeaveByTicket(attributes...)
+	case models.LeaveByPlateNo:
+		svc.handleLeaveByPlateNo(attributes...)
 	case models.GetBusyParkingStatus:
 		svc.handleGetBusyParkingStatus(attributes...)
 	case models.GetPlateNoByCarColor:
@@ -109,158 +506,956 @@ func (svc *ParkingLotCommandInput) commands(cmdStrs string) {
 		svc.handleGetLotNoByCarColor(attributes...)
 	case models.GetLotNoByPlateNo:
 		svc.handleGetLotNoByPlateNo(attributes...)
-	case models.Exit:
-		os.Exit(0)
+	case models.GetPlateNoByVehicleType:
+		svc.handleGetPlateNoByVehicleType(attributes...)
+	case models.GetLotNoByVehicleType:
+		svc.handleGetLotNoByVehicleType(attributes...)
+	case models.LoadTariff:
+		svc.handleLoadTariff(attributes...)
+	case models.ReserveLot:
+		svc.handleReserveLot(attributes...)
+	case models.UnreserveLot:
+		svc.handleUnreserveLot(attributes...)
+	case models.GetHistoryByPlateNo:
+		svc.handleGetHistoryByPlateNo(attributes...)
+	case models.GetHistoryByLotNo:
+		svc.handleGetHistoryByLotNo(attributes...)
+	case models.GetHistoryBetween:
+		svc.handleGetHistoryBetween(attributes...)
+	case models.GetReport:
+		svc.handleGetReport(attributes...)
 	default:
-		printf("Invalid parking lot command.")
+		svc.printFail(invalidCommandCode, "Invalid parking lot command.")
 	}
 }
 
-func (svc *ParkingLotCommandInput) handleCreateParkingLot(attrs ...string) {
-	parkingLotSvc := svc.parkingLotSvc
+func (svc *ParkingLotCommandInput) handleCreateParkingSite(attrs ...string) {
+	parkingSitesSvc := svc.parkingSitesSvc
 	if len(attrs) == 0 {
-		printf("Please input parking lot amount")
+		svc.printFail(invalidInputCode, "Please input parking lot name")
 		return
 	}
 
-	parkingLotAmountStr := attrs[0]
-	parkingLotAmount, err := strconv.Atoi(parkingLotAmountStr)
+	parkingLotSvc, err := parkingSitesSvc.Create(attrs[0])
 	if err != nil {
-		printf("Please input parking lot number after parking lot command")
+		svc.printError(err)
 		return
 	}
-	isCreated, err := parkingLotSvc.CreateParkingLot(parkingLotAmount)
-	if err != nil {
-		printf(err.Error())
+	svc.selectParkingLot(parkingLotSvc)
+
+	svc.setData(map[string]string{"lot": parkingLotSvc.Name()})
+	svc.printf("Created parking lot %s", parkingLotSvc.Name())
+}
+
+func (svc *ParkingLotCommandInput) handleUseParkingSite(attrs ...string) {
+	parkingSitesSvc := svc.parkingSitesSvc
+	if len(attrs) == 0 {
+		svc.printFail(invalidInputCode, "Please input parking lot name")
 		return
 	}
 
-	if !isCreated {
-		printf("Cannot create parking lot")
+	parkingLotSvc, err := parkingSitesSvc.Get(attrs[0])
+	if err != nil {
+		svc.printError(err)
 		return
 	}
+	svc.selectParkingLot(parkingLotSvc)
 
-	printf("Created a parking lot with %d slots", parkingLotAmount)
+	svc.setData(map[string]string{"lot": parkingLotSvc.Name()})
+	svc.printf("Using parking lot %s", parkingLotSvc.Name())
 }
 
-func (svc *ParkingLotCommandInput) handleParkInLot(attrs ...string) {
-	parkingLotSvc := svc.parkingLotSvc
-	if len(attrs) == 0 {
-		printf("Please input registration number and car color (optional)")
+func (svc *ParkingLotCommandInput) handleGetParkingSites(attrs ...string) {
+	parkingSitesSvc := svc.parkingSitesSvc
+	if svc.isStructured() {
+		sites := []parkingSiteResponse{}
+		for _, parkingLotSvc := range parkingSitesSvc.Parkings() {
+			sites = append(sites, parkingSiteResponse{
+				Lot:       parkingLotSvc.Name(),
+				Slots:     parkingLotSvc.LotCount(),
+				Available: parkingLotSvc.AvailableLotCount(),
+				Selected:  parkingLotSvc == svc.parkingLotSvc,
+			})
+		}
+		svc.setData(map[string][]parkingSiteResponse{"lots": sites})
 		return
 	}
 
-	plateNumber := attrs[0]
-	color := ""
-	if len(attrs) == 2 {
-		color = attrs[1]
+	fmt.Fprintf(svc.writer, "%-22s%-8s%s\n", "Parking Lot", "Slots", "Available")
+	for _, parkingLotSvc := range parkingSitesSvc.Parkings() {
+		name := parkingLotSvc.Name()
+		if parkingLotSvc == svc.parkingLotSvc {
+			name = fmt.Sprintf("%s *", name)
+		}
+		fmt.Fprintf(svc.writer, "%-22s%-8d%d\n", name, parkingLotSvc.LotCount(), parkingLotSvc.AvailableLotCount())
+	}
+}
+
+func (svc *ParkingLotCommandInput) handleDropParkingSite(attrs ...string) {
+	parkingSitesSvc := svc.parkingSitesSvc
+	if len(attrs) == 0 {
+		svc.printFail(invalidInputCode, "Please input parking lot name")
+		return
 	}
 
-	car := NewCar(plateNumber, color, 1)
-	parkLot, err := parkingLotSvc.Park(car)
+	isDropped, err := parkingSitesSvc.Drop(attrs[0])
 	if err != nil {
-		printf(err.Error())
+		svc.printError(err)
 		return
 	}
-	if parkLot == nil {
-		printf("Cannot park at parking lot")
+	if !isDropped {
+		svc.printFail(commandFailedCode, "Cannot drop parking lot")
 		return
 	}
+	if svc.parkingLotName == attrs[0] {
+		svc.selectParkingLot(nil)
+	}
 
-	printf("Allocated slot number: %d", parkLot.lotNo)
+	svc.setData(map[string]string{"lot": attrs[0]})
+	svc.printf("Dropped parking lot %s", attrs[0])
 }
 
-func (svc *ParkingLotCommandInput) handleLeaveFromLot(attrs ...string) {
-	parkingLotSvc := svc.parkingLotSvc
+func (svc *ParkingLotCommandInput) handleSaveSnapshot(attrs ...string) {
+	parkingSitesSvc := svc.parkingSitesSvc
 	if len(attrs) == 0 {
-		printf("Please input registration number and car color (optional)")
+		svc.printFail(invalidInputCode, "Please input snapshot file")
 		return
 	}
 
-	lotNoStr := attrs[0]
-	lotNo, err := strconv.Atoi(lotNoStr)
+	if err := SaveSnapshot(parkingSitesSvc, attrs[0]); err != nil {
+		svc.printError(err)
+		return
+	}
+
+	svc.setData(map[string]string{"file": attrs[0]})
+	svc.printf("Saved parking lots to %s", attrs[0])
+}
+
+// readFile is local function for read file of load command, content from journal is used when it is set
+func (svc *ParkingLotCommandInput) readFile(path string) ([]byte, error) {
+	if svc.hasLoadedData {
+		return svc.loadedData, nil
+	}
+	data, err := ioutil.ReadFile(path)
 	if err != nil {
-		printf("Please input parking lot number after a command")
+		return nil, fmt.Errorf("File is invalid (%s)", err.Error())
+	}
+	return data, nil
+}
+
+func (svc *ParkingLotCommandInput) handleLoadSnapshot(attrs ...string) {
+	parkingSitesSvc := svc.parkingSitesSvc
+	if len(attrs) == 0 {
+		svc.printFail(invalidInputCode, "Please input snapshot file")
 		return
 	}
 
-	isLeave, err := parkingLotSvc.Leave(lotNo)
+	data, err := svc.readFile(attrs[0])
 	if err != nil {
-		printf(err.Error())
+		svc.printError(err)
+		return
+	}
+	snapshot, err := parseSnapshot(data)
+	if err != nil {
+		svc.printError(err)
 		return
 	}
-	if !isLeave {
-		printf("Cannot leave at parking lot")
+	if err := parkingSitesSvc.Restore(snapshot); err != nil {
+		svc.printError(err)
 		return
 	}
+	// Selected parking lot is kept when it is in snapshot
+	if svc.parkingLotSvc != nil {
+		parkingLotSvc, err := parkingSitesSvc.Get(svc.parkingLotName)
+		if err != nil {
+			parkingLotSvc = nil
+		}
+		svc.selectParkingLot(parkingLotSvc)
+	}
 
-	printf("Slot number %d is free", lotNo)
+	svc.setData(map[string]string{"file": attrs[0]})
+	svc.printf("Loaded parking lots from %s", attrs[0])
 }
 
-func (svc *ParkingLotCommandInput) handleGetBusyParkingStatus(attrs ...string) {
-	parkingLotSvc := svc.parkingLotSvc
+func (svc *ParkingLotCommandInput) handleGetParkingSiteByPlateNo(attrs ...string) {
+	parkingSitesSvc := svc.parkingSitesSvc
+	if len(attrs) == 0 {
+		svc.printFail(invalidInputCode, "Please input registration number")
+		return
+	}
+
+	parkingLotSvcs := parkingSitesSvc.GetParkingsWithPlateNo(attrs[0])
+	if len(parkingLotSvcs) == 0 {
+		svc.printFail(notFoundCode, "Not found")
+		return
+	}
 
-	parkingLotSvc.BusyStatusTable()
+	lots := map[string][]string{}
+	for _, parkingLotSvc := range parkingLotSvcs {
+		lotLabels := []string{}
+		for _, parkingLot := range parkingLotSvc.GetParkingLotsWithPlateNo(attrs[0]) {
+			lotLabels = append(lotLabels, parkingLot.Label())
+		}
+		lots[parkingLotSvc.Name()] = lotLabels
+		svc.printf("%s: %s", parkingLotSvc.Name(), strings.Join(lotLabels, ", "))
+	}
+	svc.setData(map[string]map[string][]string{"lots": lots})
 }
 
-func (svc *ParkingLotCommandInput) handleGetPlateNoByCarColor(attrs ...string) {
+func (svc *ParkingLotCommandInput) handleCreateParkingLot(attrs ...string) {
 	parkingLotSvc := svc.parkingLotSvc
-
 	if len(attrs) == 0 {
-		printf("Please input car colour")
+		svc.printFail(invalidInputCode, "Please input parking lot amount")
 		return
 	}
 
-	parkingLots := parkingLotSvc.GetParkingLotsWithCarColor(attrs[0])
+	parkingLotAmountStr := attrs[0]
+	parkingLotAmount, err := strconv.Atoi(parkingLotAmountStr)
+	if err != nil {
+		svc.printFail(invalidInputCode, "Please input parking lot number after parking lot command")
+		return
+	}
+	categoryRanges, err := parseLotCategoryRanges(attrs[1:]...)
+	if err != nil {
+		svc.printError(err)
+		return
+	}
 
-	plateNos := []string{}
-	for _, parkingLot := range parkingLots {
-		if parkingLot.vehicle == nil {
-			continue
-		}
-		plateNos = append(plateNos, parkingLot.vehicle.PlateNumber())
+	isCreated, err := parkingLotSvc.CreateParkingLot(parkingLotAmount, categoryRanges...)
+	if err != nil {
+		svc.printError(err)
+		return
 	}
 
-	printf("%s", strings.Join(plateNos, ", "))
+	if !isCreated {
+		svc.printFail(commandFailedCode, "Cannot create parking lot")
+		return
+	}
+
+	svc.setData(map[string]interface{}{"lot": parkingLotSvc.Name(), "slots": parkingLotSvc.LotCount()})
+	svc.printf("Created a parking lot with %d slots", parkingLotAmount)
 }
 
-func (svc *ParkingLotCommandInput) handleGetLotNoByCarColor(attrs ...string) {
+func (svc *ParkingLotCommandInput) handleCreateLevel(attrs ...string) {
 	parkingLotSvc := svc.parkingLotSvc
-
 	if len(attrs) == 0 {
-		printf("Please input car colour")
+		svc.printFail(invalidInputCode, "Please input level slot amount")
 		return
 	}
 
-	parkingLots := parkingLotSvc.GetParkingLotsWithCarColor(attrs[0])
+	lotAmount, err := strconv.Atoi(attrs[0])
+	if err != nil {
+		svc.printFail(invalidInputCode, "Please input level slot number after create level command")
+		return
+	}
+	categoryRanges, err := parseLotCategoryRanges(attrs[1:]...)
+	if err != nil {
+		svc.printError(err)
+		return
+	}
 
-	lotNos := []string{}
-	for _, parkingLot := range parkingLots {
-		lotNos = append(lotNos, fmt.Sprintf("%d", parkingLot.lotNo))
+	isCreated, err := parkingLotSvc.CreateLevel(lotAmount, categoryRanges...)
+	if err != nil {
+		svc.printError(err)
+		return
+	}
+	if !isCreated {
+		svc.printFail(commandFailedCode, "Cannot create level")
+		return
 	}
 
-	printf("%s", strings.Join(lotNos, ", "))
+	svc.setData(map[string]int{"level": len(parkingLotSvc.Levels()), "slots": lotAmount})
+	svc.printf("Created level %d with %d slots", len(parkingLotSvc.Levels()), lotAmount)
 }
 
-func (svc *ParkingLotCommandInput) handleGetLotNoByPlateNo(attrs ...string) {
+func (svc *ParkingLotCommandInput) handleSetLevelPriority(attrs ...string) {
 	parkingLotSvc := svc.parkingLotSvc
-
 	if len(attrs) == 0 {
-		printf("Please input registration number")
+		svc.printFail(invalidInputCode, "Please input level numbers (e.g. 2,1,3)")
 		return
 	}
 
-	parkingLots := parkingLotSvc.GetParkingLotsWithPlateNo(attrs[0])
+	levelNos := []int{}
+	for _, levelNoStr := range strings.Split(attrs[0], ",") {
+		levelNo, err := strconv.Atoi(strings.TrimPrefix(strings.ToUpper(levelNoStr), "L"))
+		if err != nil {
+			svc.printFail(invalidInputCode, "Please input level numbers (e.g. 2,1,3)")
+			return
+		}
+		levelNos = append(levelNos, levelNo)
+	}
 
-	plateNos := []string{}
-	for _, parkingLot := range parkingLots {
-		plateNos = append(plateNos, fmt.Sprintf("%d", parkingLot.lotNo))
+	isSet, err := parkingLotSvc.SetLevelPriority(levelNos)
+	if err != nil {
+		svc.printError(err)
+		return
+	}
+	if !isSet {
+		svc.printFail(commandFailedCode, "Cannot set level priority")
+		return
 	}
 
-	if len(plateNos) == 0 {
-		printf("Not found")
+	svc.setData(levelPriorityRequest{Levels: levelNos})
+	svc.printf("Level priority is %s", attrs[0])
+}
+
+func (svc *ParkingLotCommandInput) handleSetLotPreference(attrs ...string) {
+	parkingLotSvc := svc.parkingLotSvc
+	if len(attrs) < 2 {
+		svc.printFail(invalidInputCode, "Please input vehicle type and slot categories (e.g. truck large,compact)")
 		return
 	}
 
-	printf("%s", strings.Join(plateNos, ", "))
+	vehicleType := models.VehicleType(strings.ToLower(attrs[0]))
+	categories := []models.LotCategory{}
+	for _, category := range strings.Split(attrs[1], ",") {
+		categories = append(categories, models.LotCategory(strings.ToLower(category)))
+	}
+
+	isSet, err := parkingLotSvc.SetLotPreference(vehicleType, categories)
+	if err != nil {
+		svc.printError(err)
+		return
+	}
+	if !isSet {
+		svc.printFail(commandFailedCode, "Cannot set slot preference")
+		return
+	}
+
+	svc.setData(slotPreferenceRequest{VehicleType: vehicleType, Categories: categories})
+	svc.printf("Slot preference of %s is %s", vehicleType, attrs[1])
+}
+
+func (svc *ParkingLotCommandInput) handleSetAllocationStrategy(attrs ...string) {
+	parkingLotSvc := svc.parkingLotSvc
+	if len(attrs) == 0 {
+		svc.printFail(invalidInputCode, "Please input allocation strategy (nearest, farthest, round_robin, balanced, closest_exit ${exit_slot}, random ${seed})")
+		return
+	}
+
+	name := models.AllocationStrategy(strings.ToLower(attrs[0]))
+	strategyAttrs := StrategyAttributes{}
+	switch name {
+	case models.ClosestExitStrategy:
+		if len(attrs) < 2 {
+			svc.printFail(invalidInputCode, "Please input exit slot number")
+			return
+		}
+		exitLotNo, err := parkingLotSvc.ParseLotNo(attrs[1])
+		if err != nil {
+			svc.printError(err)
+			return
+		}
+		if parkingLotSvc.ParkingLotWithNo(exitLotNo) == nil {
+			svc.printError(errmsgs.LotNoInvalidError())
+			return
+		}
+		strategyAttrs.ExitLotNo = exitLotNo
+	case models.RandomStrategy:
+		if len(attrs) >= 2 {
+			seed, err := strconv.ParseInt(attrs[1], 10, 64)
+			if err != nil {
+				svc.printFail(invalidInputCode, "Seed is invalid")
+				return
+			}
+			strategyAttrs.Seed = seed
+		}
+	}
+
+	strategy, err := NewAllocationStrategy(name, strategyAttrs)
+	if err != nil {
+		svc.printError(err)
+		return
+	}
+	if err := parkingLotSvc.SetAllocationStrategy(strategy); err != nil {
+		svc.printError(err)
+		return
+	}
+
+	svc.setData(map[string]models.AllocationStrategy{"strategy": name})
+	svc.printf("Allocation strategy is %s", strings.Join(attrs, " "))
+}
+
+func (svc *ParkingLotCommandInput) handleParkInLot(attrs ...string) {
+	parkingLotSvc := svc.parkingLotSvc
+	if len(attrs) == 0 {
+		svc.printFail(invalidInputCode, "Please input registration number and car color (optional)")
+		return
+	}
+
+	showTicket := false
+	vehicleAttrs := []string{}
+	for _, attr := range attrs {
+		if attr == ticketOption {
+			showTicket = true
+			continue
+		}
+		vehicleAttrs = append(vehicleAttrs, attr)
+	}
+
+	car, err := parseVehicle(vehicleAttrs...)
+	if err != nil {
+		svc.printError(err)
+		return
+	}
+
+	parkLot, err := parkingLotSvc.Park(car)
+	if err != nil {
+		svc.printError(err)
+		return
+	}
+	if parkLot == nil {
+		svc.printFail(commandFailedCode, "Cannot park at parking lot")
+		return
+	}
+
+	message := fmt.Sprintf("Allocated slot number: %s", parkLot.Label())
+	alloc := parkLot.allocationOf(car)
+	if alloc != nil && len(alloc.lotNos) > 1 {
+		message = fmt.Sprintf("Allocated slot numbers: %s", svc.joinLotLabels(alloc.lotNos))
+	}
+	if alloc != nil && showTicket {
+		message = fmt.Sprintf("%s, ticket %s", message, alloc.TicketID())
+	}
+	if alloc != nil {
+		svc.setData(newAllocationResponse(parkingLotSvc, alloc))
+	}
+
+	svc.printf("%s", message)
+}
+
+func (svc *ParkingLotCommandInput) handleLeaveFromLot(attrs ...string) {
+	parkingLotSvc := svc.parkingLotSvc
+	if len(attrs) == 0 {
+		svc.printFail(invalidInputCode, "Please input parking lot number and registration number (optional)")
+		return
+	}
+
+	lotNoStr := attrs[0]
+	lotNo, err := parkingLotSvc.ParseLotNo(lotNoStr)
+	if err != nil {
+		svc.printFail(invalidInputCode, "Please input parking lot number after a command")
+		return
+	}
+	plateNumber := ""
+	showDuration := false
+	for _, attr := range attrs[1:] {
+		if attr == durationOption {
+			showDuration = true
+			continue
+		}
+		plateNumber = attr
+	}
+
+	alloc, err := parkingLotSvc.LeaveVehicle(lotNo, plateNumber)
+	if err != nil {
+		svc.printError(err)
+		return
+	}
+
+	svc.printLeave(alloc, showDuration)
+}
+
+func (svc *ParkingLotCommandInput) handleLeaveByTicket(attrs ...string) {
+	parkingLotSvc := svc.parkingLotSvc
+	if len(attrs) == 0 {
+		svc.printFail(invalidInputCode, "Please input ticket id")
+		return
+	}
+	showDuration := len(attrs) >= 2 && attrs[1] == durationOption
+
+	alloc, err := parkingLotSvc.LeaveByTicket(attrs[0])
+	if err != nil {
+		svc.printError(err)
+		return
+	}
+
+	svc.printLeave(alloc, showDuration)
+}
+
+func (svc *ParkingLotCommandInput) handleLeaveByPlateNo(attrs ...string) {
+	parkingLotSvc := svc.parkingLotSvc
+	if len(attrs) == 0 {
+		svc.printFail(invalidInputCode, "Please input registration number")
+		return
+	}
+	showDuration := len(attrs) >= 2 && attrs[1] == durationOption
+
+	alloc, err := parkingLotSvc.LeaveByPlateNo(attrs[0])
+	if err != nil {
+		svc.printError(err)
+		return
+	}
+
+	svc.printLeave(alloc, showDuration)
+}
+
+// printLeave is print free lots of allocation that leave with duration and charge
+func (svc *ParkingLotCommandInput) printLeave(alloc *Allocation, showDuration bool) {
+	parkingLotSvc := svc.parkingLotSvc
+	if alloc == nil {
+		svc.printFail(commandFailedCode, "Cannot leave at parking lot")
+		return
+	}
+
+	lotNo := alloc.lotNos[0]
+	message := fmt.Sprintf("Slot number %s is free", svc.lotLabel(lotNo))
+	if len(alloc.lotNos) > 1 {
+		message = fmt.Sprintf("Slot numbers %s are free", svc.joinLotLabels(alloc.lotNos))
+	} else if parkingLot := parkingLotSvc.ParkingLotWithNo(lotNo); parkingLot != nil && len(parkingLot.allocations) != 0 {
+		message = fmt.Sprintf("Registration number %s left slot number %s", alloc.vehicle.PlateNumber(), svc.lotLabel(lotNo))
+	}
+	if showDuration {
+		message = fmt.Sprintf("%s, parked for %s", message, formatDuration(alloc.Duration()))
+	}
+	if parkingLotSvc.Tariff() != nil {
+		message = fmt.Sprintf("%s, charge %.2f", message, alloc.Fee())
+	}
+	svc.setData(newAllocationResponse(parkingLotSvc, alloc))
+
+	svc.printf("%s", message)
+}
+
+func (svc *ParkingLotCommandInput) handleLoadTariff(attrs ...string) {
+	parkingLotSvc := svc.parkingLotSvc
+	if len(attrs) == 0 {
+		svc.printFail(invalidInputCode, "Please input tariff config file")
+		return
+	}
+
+	data, err := svc.readFile(attrs[0])
+	if err != nil {
+		svc.printError(err)
+		return
+	}
+	tariff, err := ParseTariff(data)
+	if err != nil {
+		svc.printError(err)
+		return
+	}
+	if err := parkingLotSvc.SetTariff(tariff); err != nil {
+		svc.printError(err)
+		return
+	}
+
+	svc.setData(map[string]string{"file": attrs[0]})
+	svc.printf("Loaded tariff from %s", attrs[0])
+}
+
+func (svc *ParkingLotCommandInput) handleReserveLot(attrs ...string) {
+	parkingLotSvc := svc.parkingLotSvc
+	if len(attrs) < 2 {
+		svc.printFail(invalidInputCode, "Please input parking lot number and registration number")
+		return
+	}
+
+	lotNo, err := parkingLotSvc.ParseLotNo(attrs[0])
+	if err != nil {
+		svc.printFail(invalidInputCode, "Please input parking lot number after a command")
+		return
+	}
+	plateNumber := attrs[1]
+
+	isReserved, err := parkingLotSvc.Reserve(lotNo, plateNumber)
+	if err != nil {
+		svc.printError(err)
+		return
+	}
+	if !isReserved {
+		svc.printFail(commandFailedCode, "Cannot reserve at parking lot")
+		return
+	}
+
+	svc.setData(reservationRequest{Slot: svc.lotLabel(lotNo), RegistrationNumber: plateNumber})
+	svc.printf("Slot number %s is reserved for %s", svc.lotLabel(lotNo), plateNumber)
+}
+
+func (svc *ParkingLotCommandInput) handleUnreserveLot(attrs ...string) {
+	parkingLotSvc := svc.parkingLotSvc
+	if len(attrs) == 0 {
+		svc.printFail(invalidInputCode, "Please input parking lot number")
+		return
+	}
+
+	lotNo, err := parkingLotSvc.ParseLotNo(attrs[0])
+	if err != nil {
+		svc.printFail(invalidInputCode, "Please input parking lot number after a command")
+		return
+	}
+
+	isUnreserved, err := parkingLotSvc.Unreserve(lotNo)
+	if err != nil {
+		svc.printError(err)
+		return
+	}
+	if !isUnreserved {
+		svc.printFail(commandFailedCode, "Cannot unreserve at parking lot")
+		return
+	}
+
+	svc.setData(map[string]string{"slot": svc.lotLabel(lotNo)})
+	svc.printf("Slot number %s is unreserved", svc.lotLabel(lotNo))
+}
+
+func (svc *ParkingLotCommandInput) handleGetBusyParkingStatus(attrs ...string) {
+	parkingLotSvc := svc.parkingLotSvc
+
+	showEntryTime := false
+	for _, attr := range attrs {
+		if attr == sinceOption {
+			showEntryTime = true
+		}
+	}
+
+	if svc.isStructured() {
+		svc.setData(map[string][]statusResponse{"slots": newStatusResponses(parkingLotSvc)})
+		return
+	}
+	parkingLotSvc.BusyStatusTable(svc.writer, showEntryTime)
+}
+
+func (svc *ParkingLotCommandInput) handleGetPlateNoByCarColor(attrs ...string) {
+	parkingLotSvc := svc.parkingLotSvc
+
+	if len(attrs) == 0 {
+		svc.printFail(invalidInputCode, "Please input car colour")
+		return
+	}
+
+	parkingLots := parkingLotSvc.GetParkingLotsWithCarColor(attrs[0])
+
+	// Vehicle that use many lots will show once at its first lot
+	plateNos := []string{}
+	for _, parkingLot := range parkingLots {
+		for _, alloc := range parkingLot.allocations {
+			if alloc.lotNos[0] != parkingLot.lotNo || !strings.EqualFold(alloc.vehicle.Color(), attrs[0]) {
+				continue
+			}
+			plateNos = append(plateNos, alloc.vehicle.PlateNumber())
+		}
+	}
+
+	svc.setData(map[string][]string{"registration_numbers": plateNos})
+	svc.printf("%s", strings.Join(plateNos, ", "))
+}
+
+func (svc *ParkingLotCommandInput) handleGetLotNoByCarColor(attrs ...string) {
+	parkingLotSvc := svc.parkingLotSvc
+
+	if len(attrs) == 0 {
+		svc.printFail(invalidInputCode, "Please input car colour")
+		return
+	}
+
+	parkingLots := parkingLotSvc.GetParkingLotsWithCarColor(attrs[0])
+
+	lotNos := []string{}
+	for _, parkingLot := range parkingLots {
+		lotNos = append(lotNos, parkingLot.Label())
+	}
+
+	svc.setData(map[string][]string{"slots": lotNos})
+	svc.printf("%s", strings.Join(lotNos, ", "))
+}
+
+func (svc *ParkingLotCommandInput) handleGetPlateNoByVehicleType(attrs ...string) {
+	parkingLotSvc := svc.parkingLotSvc
+
+	if len(attrs) == 0 {
+		svc.printFail(invalidInputCode, "Please input vehicle type")
+		return
+	}
+	vehicleType := models.VehicleType(strings.ToLower(attrs[0]))
+
+	parkingLots := parkingLotSvc.GetParkingLotsWithVehicleType(vehicleType)
+
+	// Vehicle that use many lots will show once at its first lot
+	plateNos := []string{}
+	for _, parkingLot := range parkingLots {
+		for _, alloc := range parkingLot.allocations {
+			if alloc.lotNos[0] != parkingLot.lotNo || alloc.vehicle.Type() != vehicleType {
+				continue
+			}
+			plateNos = append(plateNos, alloc.vehicle.PlateNumber())
+		}
+	}
+
+	svc.setData(map[string][]string{"registration_numbers": plateNos})
+	svc.printf("%s", strings.Join(plateNos, ", "))
+}
+
+func (svc *ParkingLotCommandInput) handleGetLotNoByVehicleType(attrs ...string) {
+	parkingLotSvc := svc.parkingLotSvc
+
+	if len(attrs) == 0 {
+		svc.printFail(invalidInputCode, "Please input vehicle type")
+		return
+	}
+
+	parkingLots := parkingLotSvc.GetParkingLotsWithVehicleType(models.VehicleType(strings.ToLower(attrs[0])))
+
+	lotNos := []string{}
+	for _, parkingLot := range parkingLots {
+		lotNos = append(lotNos, parkingLot.Label())
+	}
+
+	svc.setData(map[string][]string{"slots": lotNos})
+	svc.printf("%s", strings.Join(lotNos, ", "))
+}
+
+func (svc *ParkingLotCommandInput) handleGetLotNoByPlateNo(attrs ...string) {
+	parkingLotSvc := svc.parkingLotSvc
+
+	if len(attrs) == 0 {
+		svc.printFail(invalidInputCode, "Please input registration number")
+		return
+	}
+
+	parkingLots := parkingLotSvc.GetParkingLotsWithPlateNo(attrs[0])
+
+	plateNos := []string{}
+	for _, parkingLot := range parkingLots {
+		plateNos = append(plateNos, parkingLot.Label())
+	}
+
+	if len(plateNos) == 0 {
+		svc.printFail(notFoundCode, "Not found")
+		return
+	}
+
+	svc.setData(map[string][]string{"slots": plateNos})
+	svc.printf("%s", strings.Join(plateNos, ", "))
+}
+
+func (svc *ParkingLotCommandInput) handleGetHistoryByPlateNo(attrs ...string) {
+	parkingLotSvc := svc.parkingLotSvc
+
+	if len(attrs) == 0 {
+		svc.printFail(invalidInputCode, "Please input registration number")
+		return
+	}
+
+	svc.printHistory(parkingLotSvc.GetHistoryWithPlateNo(attrs[0]))
+}
+
+func (svc *ParkingLotCommandInput) handleGetHistoryByLotNo(attrs ...string) {
+	parkingLotSvc := svc.parkingLotSvc
+
+	if len(attrs) == 0 {
+		svc.printFail(invalidInputCode, "Please input parking lot number")
+		return
+	}
+
+	lotNo, err := parkingLotSvc.ParseLotNo(attrs[0])
+	if err != nil {
+		svc.printFail(invalidInputCode, "Please input parking lot number after a command")
+		return
+	}
+
+	svc.printHistory(parkingLotSvc.GetHistoryWithLotNo(lotNo))
+}
+
+func (svc *ParkingLotCommandInput) handleGetHistoryBetween(attrs ...string) {
+	parkingLotSvc := svc.parkingLotSvc
+
+	if len(attrs) < 2 {
+		svc.printFail(invalidInputCode, "Please input from and to time")
+		return
+	}
+
+	from, fromErr := parseHistoryTime(attrs[0])
+	to, toErr := parseHistoryTime(attrs[1])
+	if fromErr != nil || toErr != nil || to.Before(from) {
+		svc.printFail(invalidInputCode, "Please input from and to time as %s or %s", historyTimeFormat, historyDateFormat)
+		return
+	}
+
+	svc.printHistory(parkingLotSvc.GetHistoryBetween(from, to))
+}
+
+func (svc *ParkingLotCommandInput) handleGetReport(attrs ...string) {
+	parkingLotSvc := svc.parkingLotSvc
+
+	times := []string{}
+	format := ""
+	for _, attr := range attrs {
+		if attr == csvOption || attr == jsonOption {
+			format = attr
+			continue
+		}
+		times = append(times, attr)
+	}
+	if len(times) < 2 {
+		svc.printFail(invalidInputCode, "Please input from and to time")
+		return
+	}
+
+	from, fromErr := parseHistoryTime(times[0])
+	to, toErr := parseHistoryTime(times[1])
+	if fromErr != nil || toErr != nil {
+		svc.printFail(invalidInputCode, "Please input from and to time as %s or %s", historyTimeFormat, historyDateFormat)
+		return
+	}
+
+	report, err := NewReport(parkingLotSvc, from, to)
+	if err != nil {
+		svc.printError(err)
+		return
+	}
+	// Structured output has report as data, so report format option is ignored
+	if svc.isStructured() {
+		svc.setData(report)
+		return
+	}
+	switch format {
+	case csvOption:
+		report.WriteCSV(svc.writer)
+	case jsonOption:
+		data, err := json.Marshal(report)
+		if err != nil {
+			svc.printError(errmsgs.InternalServerError())
+			return
+		}
+		svc.printf("%s", data)
+	default:
+		report.Table(svc.writer)
+	}
+}
+
+// printHistory is print visits table or not found when there is no visit
+func (svc *ParkingLotCommandInput) printHistory(allocs []*Allocation) {
+	if len(allocs) == 0 {
+		svc.printFail(notFoundCode, "Not found")
+		return
+	}
+	if svc.isStructured() {
+		visits := make([]*allocationResponse, 0, len(allocs))
+		for _, alloc := range allocs {
+			visits = append(visits, newAllocationResponse(svc.parkingLotSvc, alloc))
+		}
+		svc.setData(visits)
+		return
+	}
+	svc.parkingLotSvc.HistoryTable(svc.writer, allocs)
+}
+
+// lotLabel is label of lot no that show to user
+func (svc *ParkingLotCommandInput) lotLabel(lotNo int) string {
+	return svc.parkingLotSvc.LotLabels([]int{lotNo})[0]
+}
+
+// joinLotLabels is join label of lot nos with comma
+func (svc *ParkingLotCommandInput) joinLotLabels(lotNos []int) string {
+	return strings.Join(svc.parkingLotSvc.LotLabels(lotNos), ", ")
+}
+
+// parseVehicle is build vehicle from park command attributes
+// Positional attributes are registration number, colour and size or vehicle type
+// Option attributes are --type=, --size= and --permit=
+func parseVehicle(attrs ...string) (IVehicle, error) {
+	var (
+		positions   []string
+		vehicleType = models.Car
+		vehicleAttr = VehicleAttributes{}
+	)
+	for _, attr := range attrs {
+		if !strings.HasPrefix(attr, optionPrefix) {
+			positions = append(positions, attr)
+			continue
+		}
+
+		key, value := parseOption(attr)
+		switch key {
+		case "type":
+			vehicleType = models.VehicleType(strings.ToLower(value))
+		case "size":
+			usage, ok := parseVehicleSize(value)
+			if !ok {
+				return nil, errors.New("Please input vehicle size as positive number")
+			}
+			vehicleAttr.UsageLot = usage
+		case "permit":
+			vehicleAttr.PermitNo = value
+		default:
+			return nil, fmt.Errorf("Invalid park option (%s)", attr)
+		}
+	}
+
+	if len(positions) == 0 {
+		return nil, errors.New("Please input registration number and car color (optional)")
+	}
+	plateNumber := positions[0]
+	color := ""
+	if len(positions) >= 2 {
+		color = positions[1]
+	}
+	if len(positions) >= 3 {
+		sizeOrType := strings.ToLower(positions[2])
+		if _, ok := vehicleTypeUsageLots[models.VehicleType(sizeOrType)]; ok {
+			vehicleType = models.VehicleType(sizeOrType)
+		} else if usage, ok := parseVehicleSize(sizeOrType); ok {
+			vehicleAttr.UsageLot = usage
+		} else {
+			return nil, errors.New("Please input vehicle size or vehicle type")
+		}
+	}
+
+	return NewVehicle(vehicleType, plateNumber, color, vehicleAttr)
+}
+
+// parseVehicleSize is parse positive finite vehicle size, false is not a size
+// NaN and Inf are parsed by strconv and size bigger than float32 is error of strconv
+func parseVehicleSize(value string) (float32, bool) {
+	usage, err := strconv.ParseFloat(value, 32)
+	if err != nil || math.IsNaN(usage) || math.IsInf(usage, 0) || usage <= 0 {
+		return 0, false
+	}
+	return float32(usage), true
+}
+
+// parseOption is split --key=value option to key and value
+func parseOption(attr string) (string, string) {
+	option := strings.TrimPrefix(attr, optionPrefix)
+	ind := strings.Index(option, "=")
+	if ind < 0 {
+		return option, ""
+	}
+	return option[:ind], option[ind+1:]
+}
+
+// parseLotCategoryRanges is parse category:from-to or category:lotNo attributes
+func parseLotCategoryRanges(attrs ...string) ([]LotCategoryRange, error) {
+	categoryRanges := []LotCategoryRange{}
+	for _, attr := range attrs {
+		ind := strings.Index(attr, ":")
+		if ind < 0 {
+			return nil, fmt.Errorf("Please input slot category as category:from-to (%s)", attr)
+		}
+
+		categoryRange := LotCategoryRange{Category: models.LotCategory(strings.ToLower(attr[:ind]))}
+		lotNos := strings.SplitN(attr[ind+1:], "-", 2)
+		from, err := strconv.Atoi(lotNos[0])
+		if err != nil {
+			return nil, fmt.Errorf("Please input slot category as category:from-to (%s)", attr)
+		}
+		to := from
+		if len(lotNos) == 2 {
+			if to, err = strconv.Atoi(lotNos[1]); err != nil {
+				return nil, fmt.Errorf("Please input slot category as category:from-to (%s)", attr)
+			}
+		}
+		categoryRange.From, categoryRange.To = from, to
+
+		categoryRanges = append(categoryRanges, categoryRange)
+	}
+	return categoryRanges, nil
+}
+
+// formatDuration is format duration in hours, minutes and seconds
+func formatDuration(duration time.Duration) string {
+	duration = duration.Round(time.Second)
+	hours := duration / time.Hour
+	minutes := (duration % time.Hour) / time.Minute
+	seconds := (duration % time.Minute) / time.Second
+	return fmt.Sprintf("%dh %02dm %02ds", hours, minutes, seconds)
 }
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"parkinglot/errmsgs"
+	"parkinglot/models"
+	"strings"
+)
+
+const (
+	// okStatus is status of command response that succeeded
+	okStatus = "ok"
+	// errorStatus is status of command response that failed
+	errorStatus = "error"
+
+	// invalidInputCode is error code of missing or invalid command attributes
+	invalidInputCode = "invalid_input"
+	// invalidCommandCode is error code of unknown command
+	invalidCommandCode = "invalid_command"
+	// lotNotSelectedCode is error code of command that need selected parking lot
+	lotNotSelectedCode = "parking_lot_not_selected"
+	// notFoundCode is error code of lookup that found nothing
+	notFoundCode = "not_found"
+	// commandNotAllowedCode is error code of file command that sent over network connection
+	commandNotAllowedCode = "command_not_allowed"
+	// commandFailedCode is error code of command that did not change parking lot and error that has no code
+	commandFailedCode = "command_failed"
+)
+
+// commandErrorCodes is error code of each errmsgs error, other errors are command failed
+var commandErrorCodes = map[string]string{
+	errmsgs.ParkingLotIsFullError().Error():          "parking_lot_full",
+	errmsgs.VehicalNotParkingHereError().Error():     "vehicle_not_parking_here",
+	errmsgs.VehicalAlreadyParkingError().Error():     "vehicle_already_parking",
+	errmsgs.VehicalPlateNoRequiredError().Error():    "registration_number_required",
+	errmsgs.ParkingLotNotAvailableError().Error():    "slot_not_available",
+	errmsgs.ParkingLotNotReservedError().Error():     "slot_not_reserved",
+	errmsgs.VehicleTypeInvalidError().Error():        "vehicle_type_invalid",
+	errmsgs.VehiclePermitRequiredError().Error():     "permit_required",
+	errmsgs.LotCategoryInvalidError().Error():        "slot_category_invalid",
+	errmsgs.LotCategoryRangeInvalidError().Error():   "slot_category_range_invalid",
+	errmsgs.LevelInvalidError().Error():              "level_invalid",
+	errmsgs.LotNoInvalidError().Error():              "slot_number_invalid",
+	errmsgs.ParkingSiteNotFoundError().Error():       "parking_lot_not_found",
+	errmsgs.ParkingSiteAlreadyExistError().Error():   "parking_lot_already_exists",
+	errmsgs.ParkingSiteNotEmptyError().Error():       "parking_lot_not_empty",
+	errmsgs.TariffConfigInvalidError().Error():       "tariff_config_invalid",
+	errmsgs.TicketInvalidError().Error():             "ticket_invalid",
+	errmsgs.TicketAlreadyUsedError().Error():         "ticket_already_used",
+	errmsgs.AllocationStrategyInvalidError().Error(): "allocation_strategy_invalid",
+	errmsgs.SnapshotInvalidError().Error():           "snapshot_invalid",
+	errmsgs.ReportRangeInvalidError().Error():        "report_range_invalid",
+	errmsgs.InternalServerError().Error():            "internal_error",
+}
+
+// commandResponseCSVHeader is header record of CSV output, data is JSON of command data
+var commandResponseCSVHeader = []string{"command", "status", "error_code", "message", "data"}
+
+// parkingSiteResponse is parking lot of lots command with its slots and available slots
+type parkingSiteResponse struct {
+	Lot       string `json:"lot"`
+	Slots     int    `json:"slots"`
+	Available int    `json:"available"`
+	Selected  bool   `json:"selected"`
+}
+
+// commandResponse is structured response of command for JSON and CSV output
+type commandResponse struct {
+	Command   string      `json:"command"`
+	Status    string      `json:"status"`
+	ErrorCode string      `json:"error_code,omitempty"`
+	Message   string      `json:"message,omitempty"`
+	Data      interface{} `json:"data,omitempty"`
+}
+
+// isStructured is true when responses are JSON or CSV instead of text
+func (svc *ParkingLotCommandInput) isStructured() bool {
+	return svc.output == models.JSONOutput || svc.output == models.CSVOutput
+}
+
+// beginResponse is start structured response of command, it does nothing for text output
+func (svc *ParkingLotCommandInput) beginResponse(cmdStrs string) {
+	if !svc.isStructured() {
+		return
+	}
+	svc.response = &commandResponse{
+		Command: strings.Split(cmdStrs, " ")[0],
+		Status:  okStatus,
+	}
+}
+
+// endResponse is write structured response of command, it does nothing for text output
+func (svc *ParkingLotCommandInput) endResponse() {
+	response := svc.response
+	if response == nil {
+		return
+	}
+	svc.response = nil
+
+	if svc.output == models.JSONOutput {
+		data, err := json.Marshal(response)
+		if err != nil {
+			data, _ = json.Marshal(commandResponse{Command: response.Command, Status: errorStatus, ErrorCode: "internal_error"})
+		}
+		fmt.Fprintf(svc.writer, "%s\n", data)
+		return
+	}
+
+	data := ""
+	if response.Data != nil {
+		if jsonData, err := json.Marshal(response.Data); err == nil {
+			data = string(jsonData)
+		}
+	}
+	csvWriter := csv.NewWriter(svc.writer)
+	if !svc.isCSVHeaderWritten {
+		csvWriter.Write(commandResponseCSVHeader)
+		svc.isCSVHeaderWritten = true
+	}
+	csvWriter.Write([]string{response.Command, response.Status, response.ErrorCode, response.Message, data})
+	csvWriter.Flush()
+}
+
+// printError is print error of command, error code of structured response is from error
+func (svc *ParkingLotCommandInput) printError(err error) {
+	code, ok := commandErrorCodes[err.Error()]
+	if !ok {
+		code = commandFailedCode
+	}
+	svc.printFail(code, "%s", err.Error())
+}
+
+// printFail is print message of command that failed with error code of structured response
+func (svc *ParkingLotCommandInput) printFail(code string, topic string, params ...interface{}) {
+	if svc.response != nil {
+		svc.response.Status = errorStatus
+		svc.response.ErrorCode = code
+	}
+	svc.printf(topic, params...)
+}
+
+// setData is set data of structured response, it does nothing for text output
+func (svc *ParkingLotCommandInput) setData(data interface{}) {
+	if svc.response != nil {
+		svc.response.Data = data
+	}
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"parkinglot/models"
+	"strings"
+	"testing"
+)
+
+// testOutputCommandsHelper is responses of commands in output format on parking sites with mock clock
+func testOutputCommandsHelper(output models.OutputFormats, commands string) string {
+	parkingSites := NewParkingSitesWithClock(newMockClock())
+	parkingLot, _ := parkingSites.Create(defaultParkingSiteName)
+	responses := &bytes.Buffer{}
+	parkingCommand := newParkingLotCommandInput(strings.NewReader(commands), responses, parkingSites, parkingLot)
+	parkingCommand.output = output
+	parkingCommand.start()
+	return responses.String()
+}
+
+func TestJSONOutputWithSuccess(t *testing.T) {
+	responses := testOutputCommandsHelper(models.JSONOutput, strings.Join([]string{
+		"create_parking_lot 1",
+		"park KA-01 White",
+		"park KA-02 Red",
+		"status",
+		"registration_numbers_for_cars_with_colour White",
+		"slot_number_for_registration_number KA-09",
+		"leave 1",
+		"history KA-01",
+		"unknown",
+	}, "\n"))
+
+	lines := strings.Split(strings.TrimSuffix(responses, "\n"), "\n")
+	if len(lines) != 9 {
+		t.Fatalf("Responses should have 9 lines not %d\n%s", len(lines), responses)
+	}
+
+	expected := []string{
+		`{"command":"create_parking_lot","status":"ok","message":"Created a parking lot with 1 slots","data":{"lot":"parking-lot","slots":1}}`,
+		`{"command":"park","status":"ok","message":"Allocated slot number: 1","data":{"slots":["1"],"registration_number":"KA-01","colour":"White","vehicle_type":"car","ticket":"T000001","entry_time":"2020-09-28T08:00:00Z"}}`,
+		`{"command":"park","status":"error","error_code":"parking_lot_full","message":"Sorry, parking lot is full"}`,
+		`{"command":"status","status":"ok","data":{"slots":[{"slots":["1"],"registration_number":"KA-01","colour":"White","vehicle_type":"car","ticket":"T000001","entry_time":"2020-09-28T08:00:00Z","reserved":false}]}}`,
+		`{"command":"registration_numbers_for_cars_with_colour","status":"ok","message":"KA-01","data":{"registration_numbers":["KA-01"]}}`,
+		`{"command":"slot_number_for_registration_number","status":"error","error_code":"not_found","message":"Not found"}`,
+	}
+	for ind, line := range expected {
+		if lines[ind] != line {
+			t.Errorf("Response %d should be\n%s\nnot\n%s", ind, line, lines[ind])
+		}
+	}
+
+	response := struct {
+		commandResponse
+		Data []allocationResponse `json:"data"`
+	}{}
+	if err := json.Unmarshal([]byte(lines[7]), &response); err != nil {
+		t.Fatalf("History response should be JSON")
+	}
+	if response.Status != okStatus || len(response.Data) != 1 || response.Data[0].ExitTime == nil {
+		t.Errorf("History response should have visit that left")
+	}
+
+	if lines[8] != `{"command":"unknown","status":"error","error_code":"invalid_command","message":"Invalid parking lot command."}` {
+		t.Errorf("Unknown command response should be invalid command not %s", lines[8])
+	}
+}
+
+func TestCSVOutputWithSuccess(t *testing.T) {
+	responses := testOutputCommandsHelper(models.CSVOutput, strings.Join([]string{
+		"create_parking_lot 1",
+		"leave 1",
+		"lots",
+	}, "\n"))
+
+	expected := strings.Join([]string{
+		"command,status,error_code,message,data",
+		`create_parking_lot,ok,,Created a parking lot with 1 slots,"{""lot"":""parking-lot"",""slots"":1}"`,
+		`leave,error,vehicle_not_parking_here,"Sorry, vehical not parking here",`,
+		`lots,ok,,,"{""lots"":[{""lot"":""parking-lot"",""slots"":1,""available"":1,""selected"":true}]}"`,
+		"",
+	}, "\n")
+	if responses != expected {
+		t.Errorf("Responses should be\n%s\nnot\n%s", expected, responses)
+	}
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// eventFeedBufferSize is events that kept for slow client, newer events are dropped when buffer is full
+const eventFeedBufferSize = 64
+
+// writeEvent is write event as server-sent event
+func writeEvent(w http.ResponseWriter, name string, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, data); err != nil {
+		return err
+	}
+	w.(http.Flusher).Flush()
+	return nil
+}
+
+// handleEvents is stream events of parking as server-sent events until client disconnect
+// The first event is status with current free slots
+func (svc *HTTPServer) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	parking, ok := svc.parking(w, r)
+	if !ok {
+		return
+	}
+	if _, ok := w.(http.Flusher); !ok {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "Streaming is not supported"})
+		return
+	}
+
+	// Handler is called by the caller that change parking so event is dropped instead of wait for slow client
+	events := make(chan Event, eventFeedBufferSize)
+	subscriptionID := parking.Subscribe(func(event Event) {
+		select {
+		case events <- event:
+		default:
+		}
+	})
+	defer parking.Unsubscribe(subscriptionID)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	status := map[string]interface{}{
+		"lot":         parking.Name(),
+		"free_slots":  parking.AvailableLotCount(),
+		"total_slots": parking.LotCount(),
+	}
+	if err := writeEvent(w, "status", status); err != nil {
+		return
+	}
+	for {
+		select {
+		case event := <-events:
+			if err := writeEvent(w, string(event.Type), event); err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"parkinglot/models"
+	"strings"
+	"testing"
+)
+
+// readEventHelper is read next server-sent event name and data
+func readEventHelper(reader *bufio.Reader, t *testing.T) (string, Event) {
+	var (
+		name  string
+		event Event
+	)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Event should be read (%s)", err.Error())
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event)
+		case line == "" && name != "":
+			return name, event
+		}
+	}
+}
+
+func TestHTTPEventsStreamWithSuccess(t *testing.T) {
+	httpServer := newTestHTTPServer(newMockClock())
+	testRequestHelper(httpServer, http.MethodPost, "/lots", `{"slots": 2}`, nil, t)
+	server := httptest.NewServer(httpServer)
+	defer server.Close()
+
+	response, err := http.Get(server.URL + "/events")
+	if err != nil {
+		t.Fatalf("Events should be connected (%s)", err.Error())
+	}
+	defer response.Body.Close()
+	if response.Header.Get("Content-Type") != "text/event-stream" {
+		t.Errorf("Content type should be text/event-stream")
+	}
+	reader := bufio.NewReader(response.Body)
+
+	if name, _ := readEventHelper(reader, t); name != "status" {
+		t.Errorf("First event should be status")
+	}
+
+	testRequestHelper(httpServer, http.MethodPost, "/park", `{"registration_number": "KA-01", "colour": "White"}`, nil, t)
+	name, event := readEventHelper(reader, t)
+	if name != string(models.VehicleParkedEvent) || event.RegistrationNumber != "KA-01" || event.FreeSlots != 1 {
+		t.Errorf("Event should be park of KA-01 with 1 free slot")
+	}
+
+	testRequestHelper(httpServer, http.MethodPost, "/leave", `{"slot": "1"}`, nil, t)
+	name, event = readEventHelper(reader, t)
+	if name != string(models.VehicleLeftEvent) || strings.Join(event.Slots, ",") != "1" || event.FreeSlots != 2 {
+		t.Errorf("Event should be leave of slot 1 with 2 free slots")
+	}
+
+	// Events of other parking lot are not sent
+	testRequestHelper(httpServer, http.MethodPost, "/lots", `{"name": "mall", "slots": 2}`, nil, t)
+	testRequestHelper(httpServer, http.MethodPost, "/lots", `{"slots": 1}`, nil, t)
+	name, event = readEventHelper(reader, t)
+	if name != string(models.LotCreatedEvent) || event.Lot != defaultParkingSiteName || event.TotalSlots != 3 {
+		t.Errorf("Event should be lot created of default parking lot with 3 slots")
+	}
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"parkinglot/models"
+	"time"
+)
+
+// Event is change that happened in parking with free lot count after the change
+// Vehicle fields are empty for lot events, fee is set only when vehicle leave with tariff
+type Event struct {
+	Type               models.ParkingEventType `json:"type"`
+	Lot                string                  `json:"lot"`
+	Slots              []string                `json:"slots,omitempty"`
+	RegistrationNumber string                  `json:"registration_number,omitempty"`
+	Colour             string                  `json:"colour,omitempty"`
+	VehicleType        models.VehicleType      `json:"vehicle_type,omitempty"`
+	Ticket             string                  `json:"ticket,omitempty"`
+	Fee                float64                 `json:"fee,omitempty"`
+	FreeSlots          int                     `json:"free_slots"`
+	TotalSlots         int                     `json:"total_slots"`
+	Time               time.Time               `json:"time"`
+}
+
+// EventHandler is function that receive events of parking
+// Handler is called after parking is unlocked so it can call parking, events are sent one at a time in change order
+type EventHandler func(event Event)
+
+// eventSubscriber is handler with its subscription id
+type eventSubscriber struct {
+	subscriptionID int
+	handler        EventHandler
+}
+
+// queuedEvent is event with subscribers at the time of change
+type queuedEvent struct {
+	event       Event
+	subscribers []eventSubscriber
+}
+
+// Subscribe is add handler that called after each change of parking
+// Handlers are called in subscribe order, the returned id is used for unsubscribe
+func (svc *Parking) Subscribe(handler EventHandler) int {
+	svc.mutex.Lock()
+	defer svc.mutex.Unlock()
+
+	svc.subscriptionSeqNo++
+	svc.subscribers = append(svc.subscribers, eventSubscriber{subscriptionID: svc.subscriptionSeqNo, handler: handler})
+	return svc.subscriptionSeqNo
+}
+
+// Unsubscribe is remove handler of subscription id, false when id is not subscribed
+func (svc *Parking) Unsubscribe(subscriptionID int) bool {
+	svc.mutex.Lock()
+	defer svc.mutex.Unlock()
+
+	for i, subscriber := range svc.subscribers {
+		if subscriber.subscriptionID == subscriptionID {
+			svc.subscribers = append(svc.subscribers[:i:i], svc.subscribers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// newEvent is local function for event of lots
+func (svc *Parking) newEvent(eventType models.ParkingEventType, lotNos []int) Event {
+	slots := make([]string, 0, len(lotNos))
+	for _, lotNo := range lotNos {
+		slots = append(slots, svc.parkingLotKeyValue[lotNo].Label())
+	}
+	return Event{
+		Type:  eventType,
+		Lot:   svc.name,
+		Slots: slots,
+		Time:  svc.clock.Now(),
+	}
+}
+
+// newVehicleEvent is local function for event of vehicle at lots
+func (svc *Parking) newVehicleEvent(eventType models.ParkingEventType, lotNos []int, vehicle IVehicle) Event {
+	event := svc.newEvent(eventType, lotNos)
+	event.RegistrationNumber = vehicle.PlateNumber()
+	event.Colour = vehicle.Color()
+	event.VehicleType = vehicle.Type()
+	return event
+}
+
+// newAllocationEvent is local function for event of vehicle allocation
+func (svc *Parking) newAllocationEvent(eventType models.ParkingEventType, alloc *Allocation) Event {
+	event := svc.newVehicleEvent(eventType, alloc.lotNos, alloc.vehicle)
+	event.Ticket = alloc.ticketID
+	event.Fee = alloc.fee
+	return event
+}
+
+// emit is local function for queue event with free lot count for all subscribers
+// Queued events are sent by unlock
+func (svc *Parking) emit(event Event) {
+	if len(svc.subscribers) == 0 {
+		return
+	}
+
+	event.FreeSlots = svc.availableLots.Len()
+	event.TotalSlots = len(svc.parkingLotKeyValue)
+	svc.queuedEvents = append(svc.queuedEvents, queuedEvent{event: event, subscribers: svc.subscribers})
+}
+
+// unlock is local function for unlock parking then send queued events
+// Only one caller send events at a time so subscribers receive them in change order,
+// events of other callers and of handlers that change parking are sent by the same caller
+func (svc *Parking) unlock() {
+	if len(svc.queuedEvents) == 0 || svc.isSendingEvents {
+		svc.mutex.Unlock()
+		return
+	}
+
+	svc.isSendingEvents = true
+	for len(svc.queuedEvents) != 0 {
+		queuedEvents := svc.queuedEvents
+		svc.queuedEvents = nil
+		svc.mutex.Unlock()
+
+		for _, queued := range queuedEvents {
+			for _, subscriber := range queued.subscribers {
+				subscriber.handler(queued.event)
+			}
+		}
+		svc.mutex.Lock()
+	}
+	svc.isSendingEvents = false
+	svc.mutex.Unlock()
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"parkinglot/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSubscribeEventsWithSuccess(t *testing.T) {
+	clock := newMockClock()
+	parking := NewParkingWithClock("unit-testing", clock)
+	hourlyRate := 10.0
+	tariff, _ := NewTariff(TariffConfig{TariffRule: TariffRule{HourlyRate: &hourlyRate}})
+	parking.SetTariff(tariff)
+
+	events := []Event{}
+	parking.Subscribe(func(event Event) {
+		events = append(events, event)
+	})
+
+	parking.CreateParkingLot(3)
+	parking.Park(NewCar("plate-1", "red", 2))
+	parking.Park(NewCar("plate-2", "red", 2))
+	parking.Reserve(3, "plate-3")
+	parking.Unreserve(3)
+	clock.add(time.Hour)
+	parking.LeaveByPlateNo("plate-1")
+
+	expectedTypes := []models.ParkingEventType{
+		models.LotCreatedEvent,
+		models.VehicleParkedEvent,
+		models.ParkRejectedFullEvent,
+		models.LotReservedEvent,
+		models.LotUnreservedEvent,
+		models.VehicleLeftEvent,
+	}
+	if len(events) != len(expectedTypes) {
+		t.Fatalf("Events should be %d not %d", len(expectedTypes), len(events))
+	}
+	for i, eventType := range expectedTypes {
+		if events[i].Type != eventType || events[i].Lot != "unit-testing" || events[i].TotalSlots != 3 {
+			t.Errorf("Event %d should be %s", i, eventType)
+		}
+	}
+	if strings.Join(events[1].Slots, ",") != "1,2" || events[1].RegistrationNumber != "plate-1" || events[1].Ticket != "T000001" || events[1].FreeSlots != 1 {
+		t.Errorf("Vehicle parked event should be plate-1 at 1,2 with ticket T000001 and 1 free slot")
+	}
+	if len(events[2].Slots) != 0 || events[2].RegistrationNumber != "plate-2" || events[2].VehicleType != models.Car {
+		t.Errorf("Park rejected full event should be plate-2 car without slots")
+	}
+	if events[3].FreeSlots != 0 || events[4].FreeSlots != 1 || events[5].FreeSlots != 3 {
+		t.Errorf("Free slots should be 0, 1 and 3")
+	}
+	if events[5].Fee != 10 || !events[5].Time.Equal(clock.Now()) {
+		t.Errorf("Vehicle left event should have fee 10 and exit time")
+	}
+}
+
+func TestUnsubscribeEventsWithSuccess(t *testing.T) {
+	parking := NewParking("unit-testing")
+	firstEvents, secondEvents := 0, 0
+	firstID := parking.Subscribe(func(event Event) { firstEvents++ })
+	secondID := parking.Subscribe(func(event Event) { secondEvents++ })
+	if firstID == secondID {
+		t.Errorf("Subscription ids should be different")
+	}
+
+	parking.CreateParkingLot(2)
+	if !parking.Unsubscribe(firstID) {
+		t.Errorf("Unsubscribe should be success")
+	}
+	parking.Park(NewCar("plate-1", "red", 1))
+
+	if firstEvents != 1 || secondEvents != 2 {
+		t.Errorf("Events should be 1 and 2 not %d and %d", firstEvents, secondEvents)
+	}
+	if parking.Unsubscribe(firstID) {
+		t.Errorf("Unsubscribe again should be false")
+	}
+}
+
+func TestEventHandlerCallParkingWithSuccess(t *testing.T) {
+	parking := NewParking("unit-testing")
+	parking.CreateParkingLot(2)
+
+	// Handler that read and change parking should not deadlock and its events come after current event
+	eventTypes := []models.ParkingEventType{}
+	freeSlots := []int{}
+	parking.Subscribe(func(event Event) {
+		eventTypes = append(eventTypes, event.Type)
+		freeSlots = append(freeSlots, parking.AvailableLotCount())
+		if event.Type == models.VehicleParkedEvent && event.RegistrationNumber == "plate-1" {
+			parking.Reserve(2, "plate-2")
+		}
+	})
+
+	done := make(chan struct{})
+	go func() {
+		parking.Park(NewCar("plate-1", "red", 1))
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Park should not deadlock when handler call parking")
+	}
+
+	if len(eventTypes) != 2 || eventTypes[0] != models.VehicleParkedEvent || eventTypes[1] != models.LotReservedEvent {
+		t.Errorf("Events should be park then reserve not %v", eventTypes)
+	}
+	if len(freeSlots) != 2 || freeSlots[0] != 1 || freeSlots[1] != 0 {
+		t.Errorf("Free slots that handler read should be 1 and 0 not %v", freeSlots)
+	}
+}
@@ -0,0 +1,208 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	// fileStorageNamesFile is file of file storage that keep names of parkings in created order
+	fileStorageNamesFile = "parking_lots.json"
+	// fileStorageLotPrefix is prefix of parking files so they never clash with names file
+	fileStorageLotPrefix = "lot-"
+)
+
+// FileRepository is repository that keep parking in JSON file
+// Whole file is rewritten with each change so it fit parking with small history
+type FileRepository struct {
+	mutex sync.Mutex
+
+	path   string
+	memory *MemoryRepository
+}
+
+// NewFileRepository is a repository of JSON file, file is created with first change
+func NewFileRepository(path string) *FileRepository {
+	return &FileRepository{
+		path:   path,
+		memory: NewMemoryRepository(),
+	}
+}
+
+// Load is stored parking, nil is file not exist
+func (repository *FileRepository) Load() (*ParkingSnapshot, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	data, err := ioutil.ReadFile(repository.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("File is not read (%s)", err.Error())
+	}
+
+	snapshot := ParkingSnapshot{}
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("File is not read (%s)", err.Error())
+	}
+	repository.memory.Replace(snapshotChanges(snapshot))
+	return repository.memory.Load()
+}
+
+// Save is write settings with slots and tickets that changed
+func (repository *FileRepository) Save(changes ParkingChanges) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	return repository.update(func(memory *MemoryRepository) {
+		memory.Save(changes)
+	})
+}
+
+// Replace is remove all stored slots and tickets then write changes
+func (repository *FileRepository) Replace(changes ParkingChanges) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	return repository.update(func(memory *MemoryRepository) {
+		memory.Replace(changes)
+	})
+}
+
+// update is local function for change copy of memory then rewrite file with all slots and tickets
+// Memory keep the change only when file is written, so failed change is not written with next change
+func (repository *FileRepository) update(change func(memory *MemoryRepository)) error {
+	memory := repository.memory.clone()
+	change(memory)
+
+	snapshot, _ := memory.Load()
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return fmt.Errorf("File is not written (%s)", err.Error())
+	}
+	if err := writeFileAtomic(repository.path, data); err != nil {
+		return fmt.Errorf("File is not written (%s)", err.Error())
+	}
+	repository.memory = memory
+	return nil
+}
+
+// snapshotChanges is local function for changes that write all slots and tickets of snapshot
+func snapshotChanges(snapshot ParkingSnapshot) ParkingChanges {
+	changes := ParkingChanges{
+		Settings: snapshot,
+		Slots:    make([]SlotRecord, 0, len(snapshot.Slots)),
+		Tickets:  snapshot.Tickets,
+	}
+	for _, slot := range snapshot.Slots {
+		changes.Slots = append(changes.Slots, SlotRecord{SlotSnapshot: slot})
+	}
+	return changes
+}
+
+// FileStorage is storage that keep each parking in JSON file of directory
+type FileStorage struct {
+	mutex sync.Mutex
+
+	dir string
+}
+
+// OpenFileStorage is open directory of parking files, directory is created when not exist
+func OpenFileStorage(dir string) (*FileStorage, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("File is invalid (%s)", err.Error())
+	}
+	return &FileStorage{dir: dir}, nil
+}
+
+// Names is names of stored parkings in created order
+func (storage *FileStorage) Names() ([]string, error) {
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+	return storage.names()
+}
+
+// Open is load stored parking or create new one when name is not stored
+func (storage *FileStorage) Open(name string, clock IClock) (IParking, error) {
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	parking, err := NewParkingWithRepository(name, clock, NewFileRepository(storage.path(name)))
+	if err != nil {
+		return nil, err
+	}
+
+	names, err := storage.names()
+	if err != nil {
+		return nil, err
+	}
+	for _, storedName := range names {
+		if storedName == name {
+			return parking, nil
+		}
+	}
+	return parking, storage.writeNames(append(names, name))
+}
+
+// Remove is remove stored parking file
+func (storage *FileStorage) Remove(name string) error {
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	names, err := storage.names()
+	if err != nil {
+		return err
+	}
+	storedNames := make([]string, 0, len(names))
+	for _, storedName := range names {
+		if storedName != name {
+			storedNames = append(storedNames, storedName)
+		}
+	}
+	if err := storage.writeNames(storedNames); err != nil {
+		return err
+	}
+	if err := os.Remove(storage.path(name)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("File is not written (%s)", err.Error())
+	}
+	return nil
+}
+
+// path is local function for file of parking, name is escaped so it stay in directory
+func (storage *FileStorage) path(name string) string {
+	return filepath.Join(storage.dir, fileStorageLotPrefix+url.PathEscape(name)+".json")
+}
+
+// names is local function for read names file, no file is no parking
+func (storage *FileStorage) names() ([]string, error) {
+	names := []string{}
+	data, err := ioutil.ReadFile(filepath.Join(storage.dir, fileStorageNamesFile))
+	if os.IsNotExist(err) {
+		return names, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("File is not read (%s)", err.Error())
+	}
+	if err := json.Unmarshal(data, &names); err != nil {
+		return nil, fmt.Errorf("File is not read (%s)", err.Error())
+	}
+	return names, nil
+}
+
+// writeNames is local function for rewrite names file
+func (storage *FileStorage) writeNames(names []string) error {
+	data, err := json.Marshal(names)
+	if err != nil {
+		return fmt.Errorf("File is not written (%s)", err.Error())
+	}
+	if err := writeFileAtomic(filepath.Join(storage.dir, fileStorageNamesFile), data); err != nil {
+		return fmt.Errorf("File is not written (%s)", err.Error())
+	}
+	return nil
+}
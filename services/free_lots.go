@@ -0,0 +1,164 @@
+package services
+
+import (
+	"container/heap"
+	"parkinglot/models"
+	"sort"
+)
+
+// IFreeLotAllocator is store of available lot nos that give nearest lot no
+type IFreeLotAllocator interface {
+	// Release is add lot no back to available lots
+	Release(lotNo int)
+	// Acquire is remove lot no from available lots, false if lot no is not available
+	Acquire(lotNo int) bool
+	// Nearest is the smallest available lot no
+	Nearest() (int, bool)
+	// Len is amount of available lot nos
+	Len() int
+	// LotNos is all available lot nos that sorted, use Len when only amount is needed
+	LotNos() []int
+	// Ascend is visit available lot nos from smallest until visit return false
+	// Lot nos must not change while visiting, use it when only nearest lot nos are needed
+	Ascend(visit func(lotNo int) bool)
+}
+
+// lotNoHeap is min-heap of lot nos that know index of each lot no
+type lotNoHeap struct {
+	lotNos  []int
+	indexes map[int]int
+}
+
+func (h *lotNoHeap) Len() int {
+	return len(h.lotNos)
+}
+
+func (h *lotNoHeap) Less(i, j int) bool {
+	return h.lotNos[i] < h.lotNos[j]
+}
+
+func (h *lotNoHeap) Swap(i, j int) {
+	h.lotNos[i], h.lotNos[j] = h.lotNos[j], h.lotNos[i]
+	h.indexes[h.lotNos[i]] = i
+	h.indexes[h.lotNos[j]] = j
+}
+
+func (h *lotNoHeap) Push(x interface{}) {
+	lotNo := x.(int)
+	h.indexes[lotNo] = len(h.lotNos)
+	h.lotNos = append(h.lotNos, lotNo)
+}
+
+func (h *lotNoHeap) Pop() interface{} {
+	last := len(h.lotNos) - 1
+	lotNo := h.lotNos[last]
+	h.lotNos = h.lotNos[:last]
+	delete(h.indexes, lotNo)
+	return lotNo
+}
+
+// lotNoIndexHeap is min-heap of indexes of lot no heap that ordered by their lot nos
+type lotNoIndexHeap struct {
+	indexes []int
+	lotNos  []int
+}
+
+func (h *lotNoIndexHeap) Len() int {
+	return len(h.indexes)
+}
+
+func (h *lotNoIndexHeap) Less(i, j int) bool {
+	return h.lotNos[h.indexes[i]] < h.lotNos[h.indexes[j]]
+}
+
+func (h *lotNoIndexHeap) Swap(i, j int) {
+	h.indexes[i], h.indexes[j] = h.indexes[j], h.indexes[i]
+}
+
+func (h *lotNoIndexHeap) Push(x interface{}) {
+	h.indexes = append(h.indexes, x.(int))
+}
+
+func (h *lotNoIndexHeap) Pop() interface{} {
+	last := len(h.indexes) - 1
+	index := h.indexes[last]
+	h.indexes = h.indexes[:last]
+	return index
+}
+
+// heapFreeLots is free lot allocator that release and acquire in O(log n)
+type heapFreeLots struct {
+	lotNoHeap *lotNoHeap
+}
+
+// NewHeapFreeLots is a new free lot allocator that use min-heap
+func NewHeapFreeLots() IFreeLotAllocator {
+	return &heapFreeLots{lotNoHeap: &lotNoHeap{lotNos: []int{}, indexes: map[int]int{}}}
+}
+
+func (svc *heapFreeLots) Release(lotNo int) {
+	if _, ok := svc.lotNoHeap.indexes[lotNo]; ok {
+		return
+	}
+	heap.Push(svc.lotNoHeap, lotNo)
+}
+
+func (svc *heapFreeLots) Acquire(lotNo int) bool {
+	index, ok := svc.lotNoHeap.indexes[lotNo]
+	if !ok {
+		return false
+	}
+	heap.Remove(svc.lotNoHeap, index)
+	return true
+}
+
+func (svc *heapFreeLots) Nearest() (int, bool) {
+	if svc.lotNoHeap.Len() == 0 {
+		return 0, false
+	}
+	return svc.lotNoHeap.lotNos[0], true
+}
+
+func (svc *heapFreeLots) Len() int {
+	return svc.lotNoHeap.Len()
+}
+
+func (svc *heapFreeLots) LotNos() []int {
+	lotNos := make([]int, len(svc.lotNoHeap.lotNos))
+	copy(lotNos, svc.lotNoHeap.lotNos)
+	sort.Ints(lotNos)
+	return lotNos
+}
+
+// Ascend is visit lot nos in O(log k) each where k is visited lot nos
+// Lot no is smaller than its children in heap, so only children of visited lot nos can be next
+func (svc *heapFreeLots) Ascend(visit func(lotNo int) bool) {
+	lotNos := svc.lotNoHeap.lotNos
+	if len(lotNos) == 0 {
+		return
+	}
+
+	nextIndexes := &lotNoIndexHeap{indexes: []int{0}, lotNos: lotNos}
+	for nextIndexes.Len() != 0 {
+		index := heap.Pop(nextIndexes).(int)
+		if !visit(lotNos[index]) {
+			return
+		}
+		for _, childIndex := range []int{2*index + 1, 2*index + 2} {
+			if childIndex < len(lotNos) {
+				heap.Push(nextIndexes, childIndex)
+			}
+		}
+	}
+}
+
+// lotSegment is group of lots that run of adjacent lots can not cross
+type lotSegment struct {
+	category models.LotCategory
+	levelNo  int
+}
+
+// segmentOf is segment of lot
+func (lot *ParkingLot) segmentOf() lotSegment {
+	return lotSegment{category: lot.category, levelNo: lot.levelNo}
+}
@@ -0,0 +1,173 @@
+package services
+
+import (
+	"fmt"
+	"math/rand"
+	"parkinglot/models"
+	"sort"
+	"testing"
+)
+
+func TestHeapFreeLotsWithEmptyData(t *testing.T) {
+	freeLots := NewHeapFreeLots()
+
+	if _, ok := freeLots.Nearest(); ok {
+		t.Errorf("Nearest lot should be empty")
+	}
+	if freeLots.Acquire(1) {
+		t.Errorf("Lot no 1 should not be acquired")
+	}
+	if freeLots.Len() != 0 || len(freeLots.LotNos()) != 0 {
+		t.Errorf("Free lots should be empty")
+	}
+}
+
+func TestHeapFreeLotsWithRandomOperations(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	freeLots := NewHeapFreeLots()
+	expected := map[int]bool{}
+
+	for i := 0; i < 5000; i++ {
+		lotNo := random.Intn(200) + 1
+		if random.Intn(2) == 0 {
+			freeLots.Release(lotNo)
+			expected[lotNo] = true
+		} else {
+			if freeLots.Acquire(lotNo) != expected[lotNo] {
+				t.Fatalf("Acquire lot no %d should be %t", lotNo, expected[lotNo])
+			}
+			delete(expected, lotNo)
+		}
+
+		expectedLotNos := []int{}
+		for lotNo := range expected {
+			expectedLotNos = append(expectedLotNos, lotNo)
+		}
+		sort.Ints(expectedLotNos)
+
+		lotNos := freeLots.LotNos()
+		if fmt.Sprint(lotNos) != fmt.Sprint(expectedLotNos) || freeLots.Len() != len(expectedLotNos) {
+			t.Fatalf("Free lots should be %v", expectedLotNos)
+		}
+		nearest, ok := freeLots.Nearest()
+		if ok != (len(expectedLotNos) != 0) || (ok && nearest != expectedLotNos[0]) {
+			t.Fatalf("Nearest lot should be first of %v", expectedLotNos)
+		}
+
+		ascendLotNos := []int{}
+		freeLots.Ascend(func(lotNo int) bool {
+			ascendLotNos = append(ascendLotNos, lotNo)
+			return len(ascendLotNos) < 10
+		})
+		if len(expectedLotNos) > 10 {
+			expectedLotNos = expectedLotNos[:10]
+		}
+		if fmt.Sprint(ascendLotNos) != fmt.Sprint(expectedLotNos) {
+			t.Fatalf("Ascend lots should be %v not %v", expectedLotNos, ascendLotNos)
+		}
+	}
+}
+
+// sortedAdjacentLots is nearest run of lots that found by scan all sorted available lot nos
+func sortedAdjacentLots(parking *Parking, usage float32, category models.LotCategory, levelNo int) []int {
+	var (
+		runLots []*ParkingLot
+		runSize float32
+	)
+	for _, lotNo := range parking.GetAllAvailableLotNos() {
+		parkingLot := parking.parkingLotKeyValue[lotNo]
+		if parkingLot.category != category || !parkingLot.isInLevel(levelNo) {
+			continue
+		}
+		var lastRunLot *ParkingLot
+		if len(runLots) != 0 {
+			lastRunLot = runLots[len(runLots)-1]
+		}
+		if lastRunLot == nil || lastRunLot.lotNo+1 != lotNo || lastRunLot.levelNo != parkingLot.levelNo {
+			runLots = runLots[:0]
+			runSize = 0
+		}
+		runLots = append(runLots, parkingLot)
+		runSize += parkingLot.lotSize
+		if runSize >= usage {
+			lotNos := []int{}
+			for _, runLot := range runLots {
+				lotNos = append(lotNos, runLot.lotNo)
+			}
+			return lotNos
+		}
+	}
+	return []int{}
+}
+
+// scannedSharedLot is nearest busy lot that small vehicle can share that found by scan all lots
+func scannedSharedLot(parking *Parking, vehicle IVehicle, category models.LotCategory, levelNo int) *ParkingLot {
+	for lotNo := 1; lotNo <= len(parking.parkingLotKeyValue); lotNo++ {
+		parkingLot := parking.parkingLotKeyValue[lotNo]
+		if parkingLot.category == category && parkingLot.isInLevel(levelNo) && parkingLot.isShareable(vehicle) {
+			return parkingLot
+		}
+	}
+	return nil
+}
+
+func TestGetAdjacentLotsWithRandomParkAndLeave(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	parking := NewParking("unit-testing").(*Parking)
+	parking.CreateParkingLot(20, LotCategoryRange{From: 15, To: 18, Category: models.LargeLot})
+	for i := 0; i < 3; i++ {
+		parking.CreateLevel(30,
+			LotCategoryRange{From: 1, To: 5, Category: models.LargeLot},
+			LotCategoryRange{From: 20, To: 22, Category: models.ChargingLot})
+	}
+
+	vehicleTypes := []models.VehicleType{models.Motorcycle, models.Car, models.Truck, models.Bus, models.ElectricVehicle}
+	usages := []float32{0.5, 1, 2, 3, 4}
+	categories := []models.LotCategory{models.CompactLot, models.LargeLot, models.ChargingLot}
+	levelNos := []int{anyLevelNo, 0, 1, 2, 3}
+	plateNos := []string{}
+
+	for i := 0; i < 2000; i++ {
+		if len(plateNos) != 0 && random.Intn(3) == 0 {
+			ind := random.Intn(len(plateNos))
+			if _, err := parking.LeaveByPlateNo(plateNos[ind]); err != nil {
+				t.Fatalf("Vehicle %s should be leave", plateNos[ind])
+			}
+			plateNos = append(plateNos[:ind], plateNos[ind+1:]...)
+		} else {
+			plateNo := fmt.Sprintf("plate-%d", i)
+			vehicle, _ := NewVehicle(vehicleTypes[random.Intn(len(vehicleTypes))], plateNo, "red", VehicleAttributes{})
+			if _, err := parking.Park(vehicle); err == nil {
+				plateNos = append(plateNos, plateNo)
+			}
+		}
+		if count := parking.AvailableLotCount(); count != len(parking.GetAllAvailableLotNos()) {
+			t.Fatalf("Available lot count should be %d not %d", len(parking.GetAllAvailableLotNos()), count)
+		}
+
+		for _, usage := range usages {
+			for _, category := range categories {
+				for _, levelNo := range levelNos {
+					expectedLotNos := sortedAdjacentLots(parking, usage, category, levelNo)
+					lotNos := []int{}
+					for _, parkingLot := range parking.getAdjacentLots(usage, category, levelNo) {
+						lotNos = append(lotNos, parkingLot.lotNo)
+					}
+					if fmt.Sprint(lotNos) != fmt.Sprint(expectedLotNos) {
+						t.Fatalf("Adjacent lots of %v %s level %d should be %v not %v", usage, category, levelNo, expectedLotNos, lotNos)
+					}
+				}
+			}
+		}
+
+		motorcycle, _ := NewVehicle(models.Motorcycle, "plate-shared", "red", VehicleAttributes{})
+		for _, category := range categories {
+			for _, levelNo := range levelNos {
+				expectedLot := scannedSharedLot(parking, motorcycle, category, levelNo)
+				if sharedLot := parking.getSharedLot(motorcycle, category, levelNo); sharedLot != expectedLot {
+					t.Fatalf("Shared lot of %s level %d should be %v not %v", category, levelNo, expectedLot, sharedLot)
+				}
+			}
+		}
+	}
+}
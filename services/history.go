@@ -0,0 +1,96 @@
+package services
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+const (
+	// historyTimeFormat is format of time that history between command accept
+	historyTimeFormat = "2006-01-02T15:04:05"
+	// historyDateFormat is format of date that history between command accept, time is start of day
+	historyDateFormat = "2006-01-02"
+	// stillParkingMarker is shown in history table for exit time of vehicle that still parking
+	stillParkingMarker = "-"
+)
+
+// GetHistoryWithPlateNo is all visits of vehicle with plate no in entry order
+// Visit of vehicle that still parking has zero exit time
+func (svc *Parking) GetHistoryWithPlateNo(plateNo string) []*Allocation {
+	return svc.history(func(alloc *Allocation) bool {
+		return alloc.vehicle.PlateNumber() == plateNo
+	})
+}
+
+// GetHistoryWithLotNo is all visits that use lot in entry order
+func (svc *Parking) GetHistoryWithLotNo(lotNo int) []*Allocation {
+	return svc.history(func(alloc *Allocation) bool {
+		for _, allocLotNo := range alloc.lotNos {
+			if allocLotNo == lotNo {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// GetHistoryBetween is all visits that vehicle was in parking at any time from from to to in entry order
+func (svc *Parking) GetHistoryBetween(from, to time.Time) []*Allocation {
+	return svc.history(func(alloc *Allocation) bool {
+		if alloc.entryTime.After(to) {
+			return false
+		}
+		return alloc.exitTime.IsZero() || !alloc.exitTime.Before(from)
+	})
+}
+
+// history is local function for copy visits that matched, visits with same entry time are in ticket order
+func (svc *Parking) history(isMatched func(alloc *Allocation) bool) []*Allocation {
+	svc.mutex.RLock()
+	defer svc.mutex.RUnlock()
+
+	allocs := []*Allocation{}
+	for _, alloc := range svc.tickets {
+		if isMatched(alloc) {
+			allocs = append(allocs, alloc.snapshot())
+		}
+	}
+	sort.Slice(allocs, func(i, j int) bool {
+		if !allocs[i].entryTime.Equal(allocs[j].entryTime) {
+			return allocs[i].entryTime.Before(allocs[j].entryTime)
+		}
+		return allocs[i].ticketID < allocs[j].ticketID
+	})
+	return allocs
+}
+
+// HistoryTable is format print visits with entry time, exit time and fee
+// Vehicle that use many lots will show with range of lots
+func (svc *Parking) HistoryTable(writer io.Writer, allocs []*Allocation) {
+	svc.mutex.RLock()
+	defer svc.mutex.RUnlock()
+
+	fmt.Fprintf(writer, "%-12s%-19s%-12s%-21s%-21s%s\n", "Slot No.", "Registration No", "Colour", "Entry Time", "Exit Time", "Fee")
+	for _, alloc := range allocs {
+		exitTime := stillParkingMarker
+		if !alloc.exitTime.IsZero() {
+			exitTime = alloc.exitTime.Format(timeFormat)
+		}
+		fmt.Fprintf(writer, "%-12s%-19s%-12s%-21s%-21s%.2f\n", svc.formatLotNos(alloc.lotNos), alloc.vehicle.PlateNumber(), alloc.vehicle.Color(),
+			alloc.entryTime.Format(timeFormat), exitTime, alloc.fee)
+	}
+}
+
+// parseHistoryTime is convert time (e.g. 2020-09-28T08:00:00 or 2020-09-28) in local time zone
+// RFC 3339 time with time zone is accepted too
+func parseHistoryTime(value string) (time.Time, error) {
+	if parsedTime, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsedTime, nil
+	}
+	if parsedTime, err := time.ParseInLocation(historyTimeFormat, value, time.Local); err == nil {
+		return parsedTime, nil
+	}
+	return time.ParseInLocation(historyDateFormat, value, time.Local)
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testHistoryTicketsHelper is ticket ids of visits
+func testHistoryTicketsHelper(allocs []*Allocation) string {
+	ticketIDs := make([]string, 0, len(allocs))
+	for _, alloc := range allocs {
+		ticketIDs = append(ticketIDs, alloc.TicketID())
+	}
+	return strings.Join(ticketIDs, ",")
+}
+
+func TestGetHistoryWithSuccess(t *testing.T) {
+	clock := newMockClock()
+	parking := NewParkingWithClock(defaultParkingSiteName, clock)
+	parking.CreateParkingLot(2)
+	parking.SetTariff(testTariffHelper(10))
+
+	// KA-01 park twice at lot 1, KA-02 park at lot 2 and still parking
+	parking.Park(NewCar("KA-01", "White", 1))
+	clock.add(time.Hour)
+	parking.Park(NewCar("KA-02", "Red", 1))
+	clock.add(time.Hour)
+	parking.LeaveByPlateNo("KA-01")
+	clock.add(time.Hour)
+	parking.Park(NewCar("KA-01", "White", 1))
+
+	testCases := []struct {
+		name     string
+		allocs   []*Allocation
+		expected string
+	}{
+		{"plate KA-01", parking.GetHistoryWithPlateNo("KA-01"), "T000001,T000003"},
+		{"plate KA-09", parking.GetHistoryWithPlateNo("KA-09"), ""},
+		{"lot 1", parking.GetHistoryWithLotNo(1), "T000001,T000003"},
+		{"lot 2", parking.GetHistoryWithLotNo(2), "T000002"},
+		{"08:00 to 08:30", parking.GetHistoryBetween(clock.now.Add(-3*time.Hour), clock.now.Add(-150*time.Minute)), "T000001"},
+		{"10:30 to 10:30", parking.GetHistoryBetween(clock.now.Add(-30*time.Minute), clock.now.Add(-30*time.Minute)), "T000002"},
+		{"11:00 to 12:00", parking.GetHistoryBetween(clock.now, clock.now.Add(time.Hour)), "T000002,T000003"},
+	}
+	for _, testCase := range testCases {
+		if ticketIDs := testHistoryTicketsHelper(testCase.allocs); ticketIDs != testCase.expected {
+			t.Errorf("History of %s should be %s not %s", testCase.name, testCase.expected, ticketIDs)
+		}
+	}
+
+	history := parking.GetHistoryWithPlateNo("KA-01")
+	if history[0].Fee() != 20 || history[0].Duration() != 2*time.Hour || !history[1].ExitTime().IsZero() {
+		t.Errorf("First visit of KA-01 should have fee 20 and second visit should still parking")
+	}
+}
+
+func TestHistoryCommandsWithSuccess(t *testing.T) {
+	parkingSites := NewParkingSitesWithClock(newMockClock())
+	parkingSites.Create(defaultParkingSiteName)
+	responses := testSnapshotCommandsHelper(parkingSites, strings.Join([]string{
+		"create_parking_lot 2",
+		"park KA-01 White",
+		"park KA-02 Red",
+		"leave 1",
+		"history KA-01",
+		"history_slot 2",
+		"history_between 2020-09-28T08:00:00Z 2020-09-28T09:00:00Z",
+		"history KA-09",
+		"history_between 2020-09-28 yesterday",
+	}, "\n"))
+
+	expected := strings.Join([]string{
+		"Created a parking lot with 2 slots",
+		"Allocated slot number: 1",
+		"Allocated slot number: 2",
+		"Slot number 1 is free",
+		"Slot No.    Registration No    Colour      Entry Time           Exit Time            Fee",
+		"1           KA-01              White       2020-09-28 08:00:00  2020-09-28 08:00:00  0.00",
+		"Slot No.    Registration No    Colour      Entry Time           Exit Time            Fee",
+		"2           KA-02              Red         2020-09-28 08:00:00  -                    0.00",
+		"Slot No.    Registration No    Colour      Entry Time           Exit Time            Fee",
+		"1           KA-01              White       2020-09-28 08:00:00  2020-09-28 08:00:00  0.00",
+		"2           KA-02              Red         2020-09-28 08:00:00  -                    0.00",
+		"Not found",
+		"Please input from and to time as 2006-01-02T15:04:05 or 2006-01-02",
+		"",
+	}, "\n")
+	if responses != expected {
+		t.Errorf("Responses should be\n%s\nnot\n%s", expected, responses)
+	}
+}
+
+func TestHTTPHistoryWithSuccess(t *testing.T) {
+	clock := newMockClock()
+	server := newTestHTTPServer(clock)
+	testRequestHelper(server, http.MethodPost, "/lots", `{"slots": 2}`, nil, t)
+	testRequestHelper(server, http.MethodPost, "/park", `{"registration_number": "KA-01"}`, nil, t)
+	clock.add(time.Hour)
+	testRequestHelper(server, http.MethodPost, "/leave", `{"registration_number": "KA-01"}`, nil, t)
+	testRequestHelper(server, http.MethodPost, "/park", `{"registration_number": "KA-01"}`, nil, t)
+
+	history := []allocationResponse{}
+	if status := testRequestHelper(server, http.MethodGet, "/history?registration_number=KA-01", "", &history, t); status != http.StatusOK {
+		t.Errorf("Status should be ok not %d", status)
+	}
+	if len(history) != 2 || history[0].ExitTime == nil || history[1].ExitTime != nil {
+		t.Errorf("History should have visit that left and visit that still parking")
+	}
+
+	history = []allocationResponse{}
+	testRequestHelper(server, http.MethodGet, "/history?from=2020-09-28T08:30:00Z&to=2020-09-28T08:45:00Z", "", &history, t)
+	if len(history) != 1 || history[0].Ticket != "T000001" {
+		t.Errorf("History between should have ticket T000001")
+	}
+
+	if status := testRequestHelper(server, http.MethodGet, "/history", "", nil, t); status != http.StatusBadRequest {
+		t.Errorf("Status should be bad request not %d", status)
+	}
+}
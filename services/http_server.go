@@ -0,0 +1,799 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"parkinglot/errmsgs"
+	"parkinglot/models"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultServeAddress is address that serve mode listen when address is not set
+	defaultServeAddress = ":8080"
+	// lotQuery is query parameter of parking lot name, default parking lot is used when empty
+	lotQuery = "lot"
+)
+
+// httpErrorStatuses is HTTP status of each errmsgs error, other errors are internal server error
+var httpErrorStatuses = map[string]int{
+	errmsgs.ParkingLotIsFullError().Error():          http.StatusConflict,
+	errmsgs.VehicalNotParkingHereError().Error():     http.StatusNotFound,
+	errmsgs.VehicalAlreadyParkingError().Error():     http.StatusConflict,
+	errmsgs.VehicalPlateNoRequiredError().Error():    http.StatusBadRequest,
+	errmsgs.ParkingLotNotAvailableError().Error():    http.StatusConflict,
+	errmsgs.ParkingLotNotReservedError().Error():     http.StatusConflict,
+	errmsgs.VehicleTypeInvalidError().Error():        http.StatusBadRequest,
+	errmsgs.VehiclePermitRequiredError().Error():     http.StatusBadRequest,
+	errmsgs.LotCategoryInvalidError().Error():        http.StatusBadRequest,
+	errmsgs.LotCategoryRangeInvalidError().Error():   http.StatusBadRequest,
+	errmsgs.LevelInvalidError().Error():              http.StatusBadRequest,
+	errmsgs.LotNoInvalidError().Error():              http.StatusBadRequest,
+	errmsgs.ParkingSiteNotFoundError().Error():       http.StatusNotFound,
+	errmsgs.ParkingSiteAlreadyExistError().Error():   http.StatusConflict,
+	errmsgs.ParkingSiteNotEmptyError().Error():       http.StatusConflict,
+	errmsgs.TariffConfigInvalidError().Error():       http.StatusBadRequest,
+	errmsgs.TicketInvalidError().Error():             http.StatusNotFound,
+	errmsgs.TicketAlreadyUsedError().Error():         http.StatusConflict,
+	errmsgs.AllocationStrategyInvalidError().Error(): http.StatusBadRequest,
+	errmsgs.ReportRangeInvalidError().Error():        http.StatusBadRequest,
+}
+
+// HTTPServer is REST API of parking lots that use JSON request and response
+type HTTPServer struct {
+	parkingSitesSvc IParkingSites
+	mux             *http.ServeMux
+}
+
+// lotsRequest is request of create parking lot or level
+type lotsRequest struct {
+	Name       string             `json:"name"`
+	Slots      int                `json:"slots"`
+	Categories []LotCategoryRange `json:"categories"`
+}
+
+// levelPriorityRequest is request of set level priority
+type levelPriorityRequest struct {
+	Levels []int `json:"levels"`
+}
+
+// slotPreferenceRequest is request of set slot categories of vehicle type
+type slotPreferenceRequest struct {
+	VehicleType models.VehicleType   `json:"vehicle_type"`
+	Categories  []models.LotCategory `json:"categories"`
+}
+
+// allocationStrategyRequest is request of set allocation strategy
+type allocationStrategyRequest struct {
+	Strategy models.AllocationStrategy `json:"strategy"`
+	ExitSlot string                    `json:"exit_slot"`
+	Seed     int64                     `json:"seed"`
+}
+
+// parkRequest is request of park vehicle, empty type is car
+type parkRequest struct {
+	RegistrationNumber string             `json:"registration_number"`
+	Colour             string             `json:"colour"`
+	Type               models.VehicleType `json:"type"`
+	Size               float32            `json:"size"`
+	Permit             string             `json:"permit"`
+}
+
+// leaveRequest is request of leave by one of slot, ticket or registration number
+type leaveRequest struct {
+	Slot               string `json:"slot"`
+	Ticket             string `json:"ticket"`
+	RegistrationNumber string `json:"registration_number"`
+}
+
+// reservationRequest is request of reserve slot for registration number
+type reservationRequest struct {
+	Slot               string `json:"slot"`
+	RegistrationNumber string `json:"registration_number"`
+}
+
+// allocationResponse is vehicle and slots that vehicle use
+type allocationResponse struct {
+	Slots              []string           `json:"slots"`
+	RegistrationNumber string             `json:"registration_number"`
+	Colour             string             `json:"colour"`
+	VehicleType        models.VehicleType `json:"vehicle_type"`
+	Ticket             string             `json:"ticket"`
+	EntryTime          time.Time          `json:"entry_time"`
+	ExitTime           *time.Time         `json:"exit_time,omitempty"`
+	Fee                *float64           `json:"fee,omitempty"`
+}
+
+// statusResponse is row of status, reserved slot has only slots and registration number
+type statusResponse struct {
+	Slots              []string           `json:"slots"`
+	RegistrationNumber string             `json:"registration_number"`
+	Colour             string             `json:"colour,omitempty"`
+	VehicleType        models.VehicleType `json:"vehicle_type,omitempty"`
+	Ticket             string             `json:"ticket,omitempty"`
+	EntryTime          *time.Time         `json:"entry_time,omitempty"`
+	Reserved           bool               `json:"reserved"`
+}
+
+// errorResponse is body of error response
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// NewHTTPServer is a new REST API of parking sites
+func NewHTTPServer(parkingSitesSvc IParkingSites) *HTTPServer {
+	svc := &HTTPServer{parkingSitesSvc: parkingSitesSvc, mux: http.NewServeMux()}
+	svc.mux.HandleFunc("/lots", svc.handleLots)
+	svc.mux.HandleFunc("/lots/", svc.handleLot)
+	svc.mux.HandleFunc("/levels", svc.handleLevels)
+	svc.mux.HandleFunc("/level_priority", svc.handleLevelPriority)
+	svc.mux.HandleFunc("/slot_preferences", svc.handleSlotPreferences)
+	svc.mux.HandleFunc("/allocation_strategy", svc.handleAllocationStrategy)
+	svc.mux.HandleFunc("/tariff", svc.handleTariff)
+	svc.mux.HandleFunc("/park", svc.handlePark)
+	svc.mux.HandleFunc("/leave", svc.handleLeave)
+	svc.mux.HandleFunc("/status", svc.handleStatus)
+	svc.mux.HandleFunc("/slots", svc.handleSlots)
+	svc.mux.HandleFunc("/vehicles", svc.handleVehicles)
+	svc.mux.HandleFunc("/vehicles/", svc.handleVehicle)
+	svc.mux.HandleFunc("/reservations", svc.handleReservations)
+	svc.mux.HandleFunc("/reservations/", svc.handleReservation)
+	svc.mux.HandleFunc("/history", svc.handleHistory)
+	svc.mux.HandleFunc("/report", svc.handleReport)
+	svc.mux.HandleFunc("/events", svc.handleEvents)
+	return svc
+}
+
+// ServeHTTP is route request to handler
+func (svc *HTTPServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	svc.mux.ServeHTTP(w, r)
+}
+
+// ListenAndServe is serve REST API at address
+func (svc *HTTPServer) ListenAndServe(address string) error {
+	if address == "" {
+		address = defaultServeAddress
+	}
+	return http.ListenAndServe(address, svc)
+}
+
+// writeJSON is write response body as JSON
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// writeError is write error with HTTP status of error
+func writeError(w http.ResponseWriter, err error) {
+	status, ok := httpErrorStatuses[err.Error()]
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
+
+// writeBadRequest is write error of invalid request
+func writeBadRequest(w http.ResponseWriter, message string) {
+	writeJSON(w, http.StatusBadRequest, errorResponse{Error: message})
+}
+
+// allowMethod is check request method and write method not allowed when not match
+func allowMethod(w http.ResponseWriter, r *http.Request, methods ...string) bool {
+	for _, method := range methods {
+		if r.Method == method {
+			return true
+		}
+	}
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "Method is not allowed"})
+	return false
+}
+
+// decodeRequest is read JSON request body
+func decodeRequest(w http.ResponseWriter, r *http.Request, body interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(body); err != nil {
+		writeBadRequest(w, "Request body is invalid JSON")
+		return false
+	}
+	return true
+}
+
+// parking is parking lot of lot query, default parking lot is used when empty
+func (svc *HTTPServer) parking(w http.ResponseWriter, r *http.Request) (IParking, bool) {
+	name := r.URL.Query().Get(lotQuery)
+	if name == "" {
+		name = defaultParkingSiteName
+	}
+	parking, err := svc.parkingSitesSvc.Get(name)
+	if err != nil {
+		writeError(w, err)
+		return nil, false
+	}
+	return parking, true
+}
+
+// newAllocationResponse is response of allocation with slot labels
+func newAllocationResponse(parking IParking, alloc *Allocation) *allocationResponse {
+	response := &allocationResponse{
+		Slots:              parking.LotLabels(alloc.LotNos()),
+		RegistrationNumber: alloc.Vehicle().PlateNumber(),
+		Colour:             alloc.Vehicle().Color(),
+		VehicleType:        alloc.Vehicle().Type(),
+		Ticket:             alloc.TicketID(),
+		EntryTime:          alloc.EntryTime(),
+	}
+	if exitTime := alloc.ExitTime(); !exitTime.IsZero() {
+		response.ExitTime = &exitTime
+		if parking.Tariff() != nil {
+			fee := alloc.Fee()
+			response.Fee = &fee
+		}
+	}
+	return response
+}
+
+// newStatusResponses is busy and reserved slots of parking in slot order
+func newStatusResponses(parking IParking) []statusResponse {
+	parkingLots := parking.ParkingLot()
+	lotNos := make([]int, 0, len(parkingLots))
+	for lotNo := range parkingLots {
+		lotNos = append(lotNos, lotNo)
+	}
+	sort.Ints(lotNos)
+
+	rows := []statusResponse{}
+	for _, lotNo := range lotNos {
+		parkingLot := parkingLots[lotNo]
+		if parkingLot.status == models.Reserve {
+			rows = append(rows, statusResponse{
+				Slots:              []string{parkingLot.Label()},
+				RegistrationNumber: parkingLot.reservedPlateNo,
+				Reserved:           true,
+			})
+			continue
+		}
+		for _, alloc := range parkingLot.allocations {
+			if alloc.lotNos[0] != lotNo {
+				continue
+			}
+			entryTime := alloc.entryTime
+			row := statusResponse{
+				RegistrationNumber: alloc.vehicle.PlateNumber(),
+				Colour:             alloc.vehicle.Color(),
+				VehicleType:        alloc.vehicle.Type(),
+				Ticket:             alloc.ticketID,
+				EntryTime:          &entryTime,
+			}
+			for _, allocLotNo := range alloc.lotNos {
+				row.Slots = append(row.Slots, parkingLots[allocLotNo].Label())
+			}
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+// lotLabels is label of lots
+func lotLabels(parkingLots []*ParkingLot) []string {
+	labels := make([]string, 0, len(parkingLots))
+	for _, parkingLot := range parkingLots {
+		labels = append(labels, parkingLot.Label())
+	}
+	return labels
+}
+
+// handleLots is list parking lots (GET) or create parking lot and its slots (POST)
+func (svc *HTTPServer) handleLots(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet, http.MethodPost) {
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		names := []string{}
+		for _, parking := range svc.parkingSitesSvc.Parkings() {
+			names = append(names, parking.Name())
+		}
+		writeJSON(w, http.StatusOK, map[string][]string{"lots": names})
+		return
+	}
+
+	request := lotsRequest{}
+	if !decodeRequest(w, r, &request) {
+		return
+	}
+	if request.Name == "" {
+		request.Name = defaultParkingSiteName
+	}
+	if request.Slots < 0 {
+		writeBadRequest(w, "Please input number of slots as positive number")
+		return
+	}
+
+	parking, err := svc.parkingSitesSvc.Get(request.Name)
+	if err != nil {
+		parking, err = svc.parkingSitesSvc.Create(request.Name)
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if request.Slots > 0 {
+		if _, err := parking.CreateParkingLot(request.Slots, request.Categories...); err != nil {
+			writeError(w, err)
+			return
+		}
+	}
+	writeJSON(w, http.StatusCreated, map[string]interface{}{"lot": parking.Name(), "slots": parking.LotCount()})
+}
+
+// handleLot is drop parking lot that has no vehicle (DELETE /lots/{name})
+func (svc *HTTPServer) handleLot(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodDelete) {
+		return
+	}
+
+	name := strings.TrimPrefix(r.URL.Path, "/lots/")
+	if _, err := svc.parkingSitesSvc.Drop(name); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleLevels is create level with its own slots
+func (svc *HTTPServer) handleLevels(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+	parking, ok := svc.parking(w, r)
+	if !ok {
+		return
+	}
+
+	request := lotsRequest{}
+	if !decodeRequest(w, r, &request) {
+		return
+	}
+	if request.Slots <= 0 {
+		writeBadRequest(w, "Please input number of slots as positive number")
+		return
+	}
+	if _, err := parking.CreateLevel(request.Slots, request.Categories...); err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]int{"level": len(parking.Levels()), "slots": request.Slots})
+}
+
+// handleLevelPriority is set order of levels to find available slot
+func (svc *HTTPServer) handleLevelPriority(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodPut) {
+		return
+	}
+	parking, ok := svc.parking(w, r)
+	if !ok {
+		return
+	}
+
+	request := levelPriorityRequest{}
+	if !decodeRequest(w, r, &request) {
+		return
+	}
+	if _, err := parking.SetLevelPriority(request.Levels); err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, request)
+}
+
+// handleSlotPreferences is set slot categories that vehicle type can use
+func (svc *HTTPServer) handleSlotPreferences(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodPut) {
+		return
+	}
+	parking, ok := svc.parking(w, r)
+	if !ok {
+		return
+	}
+
+	request := slotPreferenceRequest{}
+	if !decodeRequest(w, r, &request) {
+		return
+	}
+	if _, err := parking.SetLotPreference(request.VehicleType, request.Categories); err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, request)
+}
+
+// handleAllocationStrategy is set way to choose slot
+func (svc *HTTPServer) handleAllocationStrategy(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodPut) {
+		return
+	}
+	parking, ok := svc.parking(w, r)
+	if !ok {
+		return
+	}
+
+	request := allocationStrategyRequest{}
+	if !decodeRequest(w, r, &request) {
+		return
+	}
+	strategyAttrs := StrategyAttributes{Seed: request.Seed}
+	if request.Strategy == models.ClosestExitStrategy {
+		exitLotNo, err := parking.ParseLotNo(request.ExitSlot)
+		if err == nil {
+			if parking.ParkingLotWithNo(exitLotNo) == nil {
+				err = errmsgs.LotNoInvalidError()
+			}
+		}
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		strategyAttrs.ExitLotNo = exitLotNo
+	}
+
+	strategy, err := NewAllocationStrategy(request.Strategy, strategyAttrs)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := parking.SetAllocationStrategy(strategy); err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, request)
+}
+
+// handleTariff is set tariff from tariff config in request body
+func (svc *HTTPServer) handleTariff(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodPut) {
+		return
+	}
+	parking, ok := svc.parking(w, r)
+	if !ok {
+		return
+	}
+
+	config := TariffConfig{}
+	if !decodeRequest(w, r, &config) {
+		return
+	}
+	tariff, err := NewTariff(config)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := parking.SetTariff(tariff); err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, config)
+}
+
+// handlePark is park vehicle and response allocation with ticket
+func (svc *HTTPServer) handlePark(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+	parking, ok := svc.parking(w, r)
+	if !ok {
+		return
+	}
+
+	request := parkRequest{}
+	if !decodeRequest(w, r, &request) {
+		return
+	}
+	if request.RegistrationNumber == "" {
+		writeBadRequest(w, "Please input registration number")
+		return
+	}
+	if request.Type == "" {
+		request.Type = models.Car
+	}
+	if request.Size < 0 {
+		writeBadRequest(w, "Please input vehicle size as positive number")
+		return
+	}
+
+	vehicle, err := NewVehicle(request.Type, request.RegistrationNumber, request.Colour, VehicleAttributes{UsageLot: request.Size, PermitNo: request.Permit})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	parkLot, err := parking.Park(vehicle)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	alloc := parkLot.allocationOf(vehicle)
+	if alloc == nil {
+		writeError(w, errmsgs.InternalServerError())
+		return
+	}
+	writeJSON(w, http.StatusCreated, newAllocationResponse(parking, alloc))
+}
+
+// handleLeave is leave vehicle by slot, ticket or registration number
+func (svc *HTTPServer) handleLeave(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+	parking, ok := svc.parking(w, r)
+	if !ok {
+		return
+	}
+
+	request := leaveRequest{}
+	if !decodeRequest(w, r, &request) {
+		return
+	}
+
+	var (
+		alloc *Allocation
+		err   error
+	)
+	switch {
+	case request.Ticket != "":
+		alloc, err = parking.LeaveByTicket(request.Ticket)
+	case request.Slot != "":
+		var lotNo int
+		lotNo, err = parking.ParseLotNo(request.Slot)
+		if err == nil {
+			alloc, err = parking.LeaveVehicle(lotNo, request.RegistrationNumber)
+		}
+	case request.RegistrationNumber != "":
+		alloc, err = parking.LeaveByPlateNo(request.RegistrationNumber)
+	default:
+		writeBadRequest(w, "Please input slot, ticket or registration number")
+		return
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newAllocationResponse(parking, alloc))
+}
+
+// handleStatus is all busy and reserved slots in slot order
+// Vehicle that use many slots is shown once
+func (svc *HTTPServer) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	parking, ok := svc.parking(w, r)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string][]statusResponse{"slots": newStatusResponses(parking)})
+}
+
+// handleSlots is slots of vehicles by colour or vehicle type query
+func (svc *HTTPServer) handleSlots(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	parking, ok := svc.parking(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	var parkingLots []*ParkingLot
+	switch {
+	case query.Get("colour") != "":
+		parkingLots = parking.GetParkingLotsWithCarColor(query.Get("colour"))
+	case query.Get("type") != "":
+		parkingLots = parking.GetParkingLotsWithVehicleType(models.VehicleType(strings.ToLower(query.Get("type"))))
+	case query.Get("registration_number") != "":
+		parkingLots = parking.GetParkingLotsWithPlateNo(query.Get("registration_number"))
+	default:
+		writeBadRequest(w, "Please input colour, type or registration_number query")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string][]string{"slots": lotLabels(parkingLots)})
+}
+
+// handleVehicles is registration numbers of vehicles by colour or vehicle type query
+func (svc *HTTPServer) handleVehicles(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	parking, ok := svc.parking(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	var (
+		parkingLots []*ParkingLot
+		isMatched   func(vehicle IVehicle) bool
+	)
+	switch {
+	case query.Get("colour") != "":
+		colour := query.Get("colour")
+		parkingLots = parking.GetParkingLotsWithCarColor(colour)
+		isMatched = func(vehicle IVehicle) bool {
+			return strings.EqualFold(vehicle.Color(), colour)
+		}
+	case query.Get("type") != "":
+		vehicleType := models.VehicleType(strings.ToLower(query.Get("type")))
+		parkingLots = parking.GetParkingLotsWithVehicleType(vehicleType)
+		isMatched = func(vehicle IVehicle) bool {
+			return vehicle.Type() == vehicleType
+		}
+	default:
+		writeBadRequest(w, "Please input colour or type query")
+		return
+	}
+
+	plateNos := []string{}
+	isAdded := map[string]bool{}
+	for _, parkingLot := range parkingLots {
+		for _, vehicle := range parkingLot.Vehicles() {
+			if isMatched(vehicle) && !isAdded[vehicle.PlateNumber()] {
+				isAdded[vehicle.PlateNumber()] = true
+				plateNos = append(plateNos, vehicle.PlateNumber())
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string][]string{"registration_numbers": plateNos})
+}
+
+// handleVehicle is allocation of vehicle by registration number (GET /vehicles/{plate})
+func (svc *HTTPServer) handleVehicle(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	parking, ok := svc.parking(w, r)
+	if !ok {
+		return
+	}
+
+	plateNo := strings.TrimPrefix(r.URL.Path, "/vehicles/")
+	parkingLots := parking.GetParkingLotsWithPlateNo(plateNo)
+	if len(parkingLots) == 0 {
+		writeError(w, errmsgs.VehicalNotParkingHereError())
+		return
+	}
+	for _, alloc := range parkingLots[0].allocations {
+		if alloc.vehicle.PlateNumber() == plateNo {
+			writeJSON(w, http.StatusOK, newAllocationResponse(parking, alloc))
+			return
+		}
+	}
+	writeError(w, errmsgs.VehicalNotParkingHereError())
+}
+
+// handleHistory is visits by registration number, slot or from and to time including vehicles that left
+func (svc *HTTPServer) handleHistory(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	parking, ok := svc.parking(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	var allocs []*Allocation
+	switch {
+	case query.Get("registration_number") != "":
+		allocs = parking.GetHistoryWithPlateNo(query.Get("registration_number"))
+	case query.Get("slot") != "":
+		lotNo, err := parking.ParseLotNo(query.Get("slot"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		allocs = parking.GetHistoryWithLotNo(lotNo)
+	case query.Get("from") != "" && query.Get("to") != "":
+		from, fromErr := parseHistoryTime(query.Get("from"))
+		to, toErr := parseHistoryTime(query.Get("to"))
+		if fromErr != nil || toErr != nil || to.Before(from) {
+			writeBadRequest(w, "Please input from and to time as RFC 3339 time")
+			return
+		}
+		allocs = parking.GetHistoryBetween(from, to)
+	default:
+		writeBadRequest(w, "Please input registration_number, slot or from and to query")
+		return
+	}
+
+	response := make([]*allocationResponse, 0, len(allocs))
+	for _, alloc := range allocs {
+		response = append(response, newAllocationResponse(parking, alloc))
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// handleReport is occupancy report between from and to time as JSON or CSV (format=csv)
+func (svc *HTTPServer) handleReport(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	parking, ok := svc.parking(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	from, fromErr := parseHistoryTime(query.Get("from"))
+	to, toErr := parseHistoryTime(query.Get("to"))
+	if fromErr != nil || toErr != nil {
+		writeBadRequest(w, "Please input from and to time as RFC 3339 time")
+		return
+	}
+
+	report, err := NewReport(parking, from, to)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if query.Get("format") == "csv" {
+		w.Header().Set("Content-Type", "text/csv")
+		report.WriteCSV(w)
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+// handleReservations is reserve slot for registration number
+func (svc *HTTPServer) handleReservations(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+	parking, ok := svc.parking(w, r)
+	if !ok {
+		return
+	}
+
+	request := reservationRequest{}
+	if !decodeRequest(w, r, &request) {
+		return
+	}
+	if request.RegistrationNumber == "" {
+		writeBadRequest(w, "Please input registration number")
+		return
+	}
+	lotNo, err := parking.ParseLotNo(request.Slot)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if _, err := parking.Reserve(lotNo, request.RegistrationNumber); err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, request)
+}
+
+// handleReservation is release reserved slot (DELETE /reservations/{slot})
+func (svc *HTTPServer) handleReservation(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodDelete) {
+		return
+	}
+	parking, ok := svc.parking(w, r)
+	if !ok {
+		return
+	}
+
+	lotNo, err := parking.ParseLotNo(strings.TrimPrefix(r.URL.Path, "/reservations/"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if _, err := parking.Unreserve(lotNo); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,179 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"parkinglot/errmsgs"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestHTTPServer is HTTP server with default parking lot that use mock clock
+func newTestHTTPServer(clock IClock) *HTTPServer {
+	parkingSites := NewParkingSitesWithClock(clock)
+	parkingSites.Create(defaultParkingSiteName)
+	return NewHTTPServer(parkingSites)
+}
+
+// testRequestHelper is send request to server and decode JSON response body
+func testRequestHelper(server *HTTPServer, method, path, body string, response interface{}, t *testing.T) int {
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest(method, path, strings.NewReader(body)))
+	if response != nil && recorder.Body.Len() != 0 {
+		if err := json.Unmarshal(recorder.Body.Bytes(), response); err != nil {
+			t.Errorf("Response body should be JSON (%s)", recorder.Body.String())
+		}
+	}
+	return recorder.Code
+}
+
+func TestHTTPCreateLotsAndParkWithSuccess(t *testing.T) {
+	server := newTestHTTPServer(newMockClock())
+
+	lots := map[string]interface{}{}
+	if status := testRequestHelper(server, http.MethodPost, "/lots", `{"slots": 3}`, &lots, t); status != http.StatusCreated {
+		t.Errorf("Status should be created not %d", status)
+	}
+	if lots["lot"] != defaultParkingSiteName || lots["slots"] != float64(3) {
+		t.Errorf("Lot should be default parking lot with 3 slots")
+	}
+
+	alloc := allocationResponse{}
+	status := testRequestHelper(server, http.MethodPost, "/park", `{"registration_number": "KA-01", "colour": "White", "type": "truck"}`, &alloc, t)
+	if status != http.StatusCreated {
+		t.Errorf("Status should be created not %d", status)
+	}
+	if strings.Join(alloc.Slots, ",") != "1,2" || alloc.Ticket != "T000001" || alloc.VehicleType != "truck" {
+		t.Errorf("Truck should be parking at slots 1,2 with ticket T000001")
+	}
+
+	testRequestHelper(server, http.MethodPost, "/park", `{"registration_number": "KA-02", "colour": "red"}`, nil, t)
+
+	statusRows := map[string][]statusResponse{}
+	if status := testRequestHelper(server, http.MethodGet, "/status", "", &statusRows, t); status != http.StatusOK {
+		t.Errorf("Status should be ok not %d", status)
+	}
+	if len(statusRows["slots"]) != 2 || statusRows["slots"][1].RegistrationNumber != "KA-02" {
+		t.Errorf("Status should have 2 vehicles")
+	}
+
+	slots := map[string][]string{}
+	testRequestHelper(server, http.MethodGet, "/slots?colour=WHITE", "", &slots, t)
+	if strings.Join(slots["slots"], ",") != "1,2" {
+		t.Errorf("Slots of white should be 1,2")
+	}
+
+	vehicles := map[string][]string{}
+	testRequestHelper(server, http.MethodGet, "/vehicles?colour=red", "", &vehicles, t)
+	if strings.Join(vehicles["registration_numbers"], ",") != "KA-02" {
+		t.Errorf("Registration numbers of red should be KA-02")
+	}
+
+	alloc = allocationResponse{}
+	if status := testRequestHelper(server, http.MethodGet, "/vehicles/KA-02", "", &alloc, t); status != http.StatusOK {
+		t.Errorf("Status should be ok not %d", status)
+	}
+	if strings.Join(alloc.Slots, ",") != "3" {
+		t.Errorf("Vehicle KA-02 should be parking at slot 3")
+	}
+}
+
+func TestHTTPLeaveWithSuccess(t *testing.T) {
+	clock := newMockClock()
+	server := newTestHTTPServer(clock)
+	testRequestHelper(server, http.MethodPost, "/lots", `{"slots": 3}`, nil, t)
+	testRequestHelper(server, http.MethodPut, "/tariff", `{"hourly_rate": 10}`, nil, t)
+	for _, plateNo := range []string{"KA-01", "KA-02", "KA-03"} {
+		testRequestHelper(server, http.MethodPost, "/park", `{"registration_number": "`+plateNo+`"}`, nil, t)
+	}
+	clock.add(90 * time.Minute)
+
+	for _, body := range []string{`{"slot": "1"}`, `{"ticket": "T000002"}`, `{"registration_number": "KA-03"}`} {
+		alloc := allocationResponse{}
+		if status := testRequestHelper(server, http.MethodPost, "/leave", body, &alloc, t); status != http.StatusOK {
+			t.Errorf("Status should be ok not %d", status)
+		}
+		if alloc.ExitTime == nil || alloc.Fee == nil || *alloc.Fee != 20 {
+			t.Errorf("Leave should have exit time and fee 20")
+		}
+	}
+
+	slots := map[string][]string{}
+	testRequestHelper(server, http.MethodGet, "/slots?registration_number=KA-01", "", &slots, t)
+	if len(slots["slots"]) != 0 {
+		t.Errorf("Slots should be empty")
+	}
+}
+
+func TestHTTPErrorStatus(t *testing.T) {
+	server := newTestHTTPServer(newMockClock())
+	testRequestHelper(server, http.MethodPost, "/lots", `{"slots": 1}`, nil, t)
+	testRequestHelper(server, http.MethodPost, "/park", `{"registration_number": "KA-01"}`, nil, t)
+
+	testCases := []struct {
+		method string
+		path   string
+		body   string
+		status int
+		err    error
+	}{
+		{http.MethodPost, "/park", `{"registration_number": "KA-01"}`, http.StatusConflict, errmsgs.VehicalAlreadyParkingError()},
+		{http.MethodPost, "/park", `{"registration_number": "KA-02"}`, http.StatusConflict, errmsgs.ParkingLotIsFullError()},
+		{http.MethodPost, "/park", `{"registration_number": "KA-02", "type": "plane"}`, http.StatusBadRequest, errmsgs.VehicleTypeInvalidError()},
+		{http.MethodPost, "/park", `{"registration_number": }`, http.StatusBadRequest, nil},
+		{http.MethodPost, "/leave", `{"slot": "2"}`, http.StatusNotFound, errmsgs.VehicalNotParkingHereError()},
+		{http.MethodPost, "/leave", `{"ticket": "T000009"}`, http.StatusNotFound, errmsgs.TicketInvalidError()},
+		{http.MethodPost, "/leave", `{"slot": "L9-001"}`, http.StatusBadRequest, errmsgs.LotNoInvalidError()},
+		{http.MethodPost, "/leave", `{}`, http.StatusBadRequest, nil},
+		{http.MethodGet, "/status?lot=mall", "", http.StatusNotFound, errmsgs.ParkingSiteNotFoundError()},
+		{http.MethodGet, "/vehicles/KA-09", "", http.StatusNotFound, errmsgs.VehicalNotParkingHereError()},
+		{http.MethodDelete, "/lots/parking-lot", "", http.StatusConflict, errmsgs.ParkingSiteNotEmptyError()},
+		{http.MethodPut, "/allocation_strategy", `{"strategy": "shortest"}`, http.StatusBadRequest, errmsgs.AllocationStrategyInvalidError()},
+		{http.MethodPut, "/tariff", `{"hourly_rate": -1}`, http.StatusBadRequest, errmsgs.TariffConfigInvalidError()},
+		{http.MethodPost, "/reservations", `{"slot": "1", "registration_number": "KA-02"}`, http.StatusConflict, errmsgs.ParkingLotNotAvailableError()},
+		{http.MethodDelete, "/reservations/1", "", http.StatusConflict, errmsgs.ParkingLotNotReservedError()},
+		{http.MethodGet, "/park", "", http.StatusMethodNotAllowed, nil},
+	}
+	for _, testCase := range testCases {
+		response := errorResponse{}
+		status := testRequestHelper(server, testCase.method, testCase.path, testCase.body, &response, t)
+		if status != testCase.status {
+			t.Errorf("Status of %s %s should be %d not %d", testCase.method, testCase.path, testCase.status, status)
+		}
+		if testCase.err != nil && response.Error != testCase.err.Error() {
+			t.Errorf("Error of %s %s should be %s", testCase.method, testCase.path, testCase.err.Error())
+		}
+	}
+}
+
+func TestHTTPNamedLotsAndReservationWithSuccess(t *testing.T) {
+	server := newTestHTTPServer(newMockClock())
+
+	if status := testRequestHelper(server, http.MethodPost, "/lots", `{"name": "mall", "slots": 2}`, nil, t); status != http.StatusCreated {
+		t.Errorf("Status should be created not %d", status)
+	}
+	lots := map[string][]string{}
+	testRequestHelper(server, http.MethodGet, "/lots", "", &lots, t)
+	if strings.Join(lots["lots"], ",") != "parking-lot,mall" {
+		t.Errorf("Lots should be parking-lot,mall")
+	}
+
+	if status := testRequestHelper(server, http.MethodPost, "/reservations?lot=mall", `{"slot": "1", "registration_number": "KA-01"}`, nil, t); status != http.StatusCreated {
+		t.Errorf("Status should be created not %d", status)
+	}
+	alloc := allocationResponse{}
+	testRequestHelper(server, http.MethodPost, "/park?lot=mall", `{"registration_number": "KA-02"}`, &alloc, t)
+	if strings.Join(alloc.Slots, ",") != "2" {
+		t.Errorf("Vehicle should not park at reserved slot")
+	}
+	if status := testRequestHelper(server, http.MethodDelete, "/reservations/1?lot=mall", "", nil, t); status != http.StatusNoContent {
+		t.Errorf("Status should be no content not %d", status)
+	}
+
+	testRequestHelper(server, http.MethodPost, "/leave?lot=mall", `{"registration_number": "KA-02"}`, nil, t)
+	if status := testRequestHelper(server, http.MethodDelete, "/lots/mall", "", nil, t); status != http.StatusNoContent {
+		t.Errorf("Status should be no content not %d", status)
+	}
+}
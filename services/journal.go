@@ -0,0 +1,284 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"parkinglot/errmsgs"
+	"parkinglot/models"
+	"strings"
+	"sync"
+	"time"
+)
+
+// journalCompactEntries is entries that journal keep before it is compacted into snapshot
+var journalCompactEntries = 1000
+
+// journaledCommands are commands that change parking lots and are written to journal before run
+var journaledCommands = map[models.ParkingLotCommandInputs]bool{
+	models.CreateParkingSite:     true,
+	models.DropParkingSite:       true,
+	models.LoadSnapshot:          true,
+	models.CreateParkingLot:      true,
+	models.CreateLevel:           true,
+	models.SetLevelPriority:      true,
+	models.SetLotPreference:      true,
+	models.SetAllocationStrategy: true,
+	models.ParkInLot:             true,
+	models.LeaveFromLot:          true,
+	models.LeaveByTicket:         true,
+	models.LeaveByPlateNo:        true,
+	models.LoadTariff:            true,
+	models.ReserveLot:            true,
+	models.UnreserveLot:          true,
+}
+
+// journaledFileCommands are journaled commands that read file, content of file is written with command
+var journaledFileCommands = map[models.ParkingLotCommandInputs]bool{
+	models.LoadSnapshot: true,
+	models.LoadTariff:   true,
+}
+
+// journalEntry is command line in journal with parking lot that was selected when command run
+// Data is content of file that command loaded, HasData is true when it is written even if file is empty
+type journalEntry struct {
+	SeqNo   int       `json:"seq"`
+	Time    time.Time `json:"time"`
+	Lot     string    `json:"lot,omitempty"`
+	Command string    `json:"command"`
+	Data    []byte    `json:"data,omitempty"`
+	HasData bool      `json:"has_data,omitempty"`
+}
+
+// CommandJournal is write-ahead journal of commands that change parking lots
+// Each command is synced to file before it run, then journal is replayed on top of snapshot when start
+// Commands are run one at a time so replay run them in the same order
+type CommandJournal struct {
+	mutex sync.Mutex
+
+	file         *os.File
+	snapshotPath string
+	clock        *journalClock
+
+	// seqNo is last entry seq no, entries is amount of entries since last compaction
+	seqNo   int
+	entries []journalEntry
+}
+
+// journalClock is clock that fixed at time of journal entry while command run
+// Replayed commands get the same entry and exit time as when they were run
+type journalClock struct {
+	mutex sync.RWMutex
+	clock IClock
+	fixed time.Time
+}
+
+func (clock *journalClock) Now() time.Time {
+	clock.mutex.RLock()
+	defer clock.mutex.RUnlock()
+	if !clock.fixed.IsZero() {
+		return clock.fixed
+	}
+	return clock.clock.Now()
+}
+
+// fix is fix time of clock, zero time is use time of inner clock
+func (clock *journalClock) fix(now time.Time) {
+	clock.mutex.Lock()
+	defer clock.mutex.Unlock()
+	clock.fixed = now
+}
+
+// OpenCommandJournal is open journal file and read its entries
+// Incomplete last entry of crash while writing is removed, snapshot path is used for compaction
+func OpenCommandJournal(path, snapshotPath string, clock IClock) (*CommandJournal, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("File is invalid (%s)", err.Error())
+	}
+
+	entries, size, err := readJournalEntries(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if err := file.Truncate(size); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("File is invalid (%s)", err.Error())
+	}
+	if _, err := file.Seek(size, io.SeekStart); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("File is invalid (%s)", err.Error())
+	}
+
+	journal := &CommandJournal{
+		file:         file,
+		snapshotPath: snapshotPath,
+		clock:        &journalClock{clock: clock},
+		entries:      entries,
+	}
+	if len(entries) != 0 {
+		journal.seqNo = entries[len(entries)-1].SeqNo
+	}
+	return journal, nil
+}
+
+// readJournalEntries is local function for read complete entries and size of them
+// Reading stop at first incomplete or broken line
+func readJournalEntries(reader io.Reader) ([]journalEntry, int64, error) {
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, 0, fmt.Errorf("File is invalid (%s)", err.Error())
+	}
+
+	entries := []journalEntry{}
+	var size int64
+	for len(data) != 0 {
+		end := bytes.IndexByte(data, '\n')
+		if end < 0 {
+			break
+		}
+		entry := journalEntry{}
+		if err := json.Unmarshal(data[:end], &entry); err != nil || entry.SeqNo <= 0 {
+			break
+		}
+		entries = append(entries, entry)
+		size += int64(end + 1)
+		data = data[end+1:]
+	}
+	return entries, size, nil
+}
+
+// Clock is clock that parking sites of journal must use
+func (journal *CommandJournal) Clock() IClock {
+	return journal.clock
+}
+
+// Replay is run entries after seq no of snapshot on parking sites
+// File command is run only with data in journal, it never read file of its path again
+// Next entry continue after seq no of snapshot because compaction leave journal file empty
+func (journal *CommandJournal) Replay(parkingSitesSvc IParkingSites, snapshotSeqNo int) {
+	journal.mutex.Lock()
+	defer journal.mutex.Unlock()
+
+	if journal.seqNo < snapshotSeqNo {
+		journal.seqNo = snapshotSeqNo
+	}
+
+	parkingCommand := newParkingLotCommandInput(nil, ioutil.Discard, parkingSitesSvc, nil)
+	for _, entry := range journal.entries {
+		if entry.SeqNo <= snapshotSeqNo {
+			continue
+		}
+		parkingLotSvc, err := parkingSitesSvc.Get(entry.Lot)
+		if err != nil {
+			parkingLotSvc = nil
+		}
+		parkingCommand.selectParkingLot(parkingLotSvc)
+
+		command := models.ParkingLotCommandInputs(strings.Split(entry.Command, " ")[0])
+		if fileCommands[command] && !entry.HasData {
+			continue
+		}
+
+		journal.clock.fix(entry.Time)
+		parkingCommand.loadedData, parkingCommand.hasLoadedData = entry.Data, entry.HasData
+		parkingCommand.runCommand(entry.Command)
+		parkingCommand.loadedData, parkingCommand.hasLoadedData = nil, false
+		journal.clock.fix(time.Time{})
+	}
+}
+
+// Run is write command with data of file it load to journal then run it, command is not run when it can not be written
+// Journal is compacted when it has too many entries
+func (journal *CommandJournal) Run(parkingSitesSvc IParkingSites, lotName, command string, data []byte, hasData bool, run func()) error {
+	journal.mutex.Lock()
+	defer journal.mutex.Unlock()
+
+	entry := journalEntry{
+		SeqNo:   journal.seqNo + 1,
+		Time:    journal.clock.Now(),
+		Lot:     lotName,
+		Command: command,
+		Data:    data,
+		HasData: hasData,
+	}
+	if err := journal.append(entry); err != nil {
+		return err
+	}
+
+	journal.clock.fix(entry.Time)
+	run()
+	journal.clock.fix(time.Time{})
+
+	if journal.snapshotPath != "" && len(journal.entries) >= journalCompactEntries {
+		return journal.compact(parkingSitesSvc)
+	}
+	return nil
+}
+
+// append is local function for write entry and sync it to disk
+func (journal *CommandJournal) append(entry journalEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return errmsgs.InternalServerError()
+	}
+	writer := bufio.NewWriter(journal.file)
+	writer.Write(data)
+	writer.WriteByte('\n')
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("Journal is not written (%s)", err.Error())
+	}
+	if err := journal.file.Sync(); err != nil {
+		return fmt.Errorf("Journal is not written (%s)", err.Error())
+	}
+
+	journal.seqNo = entry.SeqNo
+	journal.entries = append(journal.entries, entry)
+	return nil
+}
+
+// Compact is save parking sites to snapshot then remove entries that snapshot include
+func (journal *CommandJournal) Compact(parkingSitesSvc IParkingSites) error {
+	journal.mutex.Lock()
+	defer journal.mutex.Unlock()
+	return journal.compact(parkingSitesSvc)
+}
+
+// compact is local function for compact journal, it does nothing without snapshot path
+// Journal without snapshot path is never compacted, command input require snapshot with journal
+// Snapshot keep seq no of last entry so entries are skipped when crash before journal is truncated
+func (journal *CommandJournal) compact(parkingSitesSvc IParkingSites) error {
+	if journal.snapshotPath == "" {
+		return nil
+	}
+
+	snapshot := parkingSitesSvc.Snapshot()
+	snapshot.JournalSeqNo = journal.seqNo
+	if err := saveSnapshotFile(snapshot, journal.snapshotPath); err != nil {
+		return err
+	}
+
+	if err := journal.file.Truncate(0); err != nil {
+		return fmt.Errorf("Journal is not compacted (%s)", err.Error())
+	}
+	if _, err := journal.file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("Journal is not compacted (%s)", err.Error())
+	}
+	if err := journal.file.Sync(); err != nil {
+		return fmt.Errorf("Journal is not compacted (%s)", err.Error())
+	}
+	journal.entries = nil
+	return nil
+}
+
+// Close is close journal file
+func (journal *CommandJournal) Close() error {
+	journal.mutex.Lock()
+	defer journal.mutex.Unlock()
+	return journal.file.Close()
+}
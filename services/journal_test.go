@@ -0,0 +1,347 @@
+package services
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"parkinglot/models"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testJournalCommands are commands that each has one line response
+var testJournalCommands = []string{
+	"create_parking_lot 4",
+	"park KA-01 White",
+	"park KA-02 Red --ticket",
+	"reserve 4 KA-09",
+	"leave 1",
+	"create_lot mall",
+	"create_parking_lot 2",
+	"park KA-03 Blue motorcycle",
+	"park KA-04 Blue motorcycle",
+	"use parking-lot",
+	"park KA-05 Black",
+	"park KA-06 Black",
+	"leave_by_registration KA-02",
+	"park KA-07 Grey",
+}
+
+// testComparableSnapshotHelper is snapshot of parking sites without time that differ between runs
+func testComparableSnapshotHelper(parkingSites IParkingSites) ParkingSitesSnapshot {
+	snapshot := parkingSites.Snapshot()
+	for _, parkingSnapshot := range snapshot.Lots {
+		for i := range parkingSnapshot.Tickets {
+			parkingSnapshot.Tickets[i].EntryTime = time.Time{}
+			parkingSnapshot.Tickets[i].ExitTime = nil
+		}
+	}
+	return snapshot
+}
+
+// TestJournalHelperProcess is not a test, it is process that run commands of standard input with journal
+// It is started and killed by TestJournalRecoverAfterKillWithSuccess
+func TestJournalHelperProcess(t *testing.T) {
+	if os.Getenv("PARKING_JOURNAL_HELPER") != "1" {
+		return
+	}
+	journalCompactEntries, _ = strconv.Atoi(os.Getenv("PARKING_JOURNAL_COMPACT_ENTRIES"))
+	NewCommandInput().
+		Type(models.InputType).
+		SnapshotFile(os.Getenv("PARKING_JOURNAL_SNAPSHOT")).
+		JournalFile(os.Getenv("PARKING_JOURNAL_FILE")).
+		Run()
+	os.Exit(0)
+}
+
+// testJournalRecoverAfterKillHelper is run commands in process with journal, kill it then compare recovered parking lots
+// with parking lots that run the same commands without crash
+func testJournalRecoverAfterKillHelper(commands []string, t *testing.T) {
+	dir, err := ioutil.TempDir("", "journal")
+	if err != nil {
+		t.Fatalf("Error should be empty")
+	}
+	defer os.RemoveAll(dir)
+	snapshotPath := filepath.Join(dir, "parking.json")
+	journalPath := filepath.Join(dir, "journal.log")
+
+	cmd := exec.Command(os.Args[0], "-test.run=TestJournalHelperProcess")
+	cmd.Env = append(os.Environ(),
+		"PARKING_JOURNAL_HELPER=1",
+		"PARKING_JOURNAL_COMPACT_ENTRIES=5",
+		"PARKING_JOURNAL_SNAPSHOT="+snapshotPath,
+		"PARKING_JOURNAL_FILE="+journalPath,
+	)
+	stdin, _ := cmd.StdinPipe()
+	stdout, _ := cmd.StdoutPipe()
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("Process should be started (%s)", err.Error())
+	}
+
+	// Each command is acknowledged before next command is sent, then process is killed without exit
+	reader := bufio.NewReader(stdout)
+	for _, command := range commands {
+		stdin.Write([]byte(command + "\n"))
+		if _, err := reader.ReadString('\n'); err != nil {
+			t.Fatalf("Response of %s should be read (%s)", command, err.Error())
+		}
+	}
+	cmd.Process.Kill()
+	cmd.Wait()
+
+	if _, err := os.Stat(snapshotPath); err != nil {
+		t.Errorf("Snapshot should be saved by compaction")
+	}
+	// Crash while writing leave incomplete last entry
+	journalFile, _ := os.OpenFile(journalPath, os.O_APPEND|os.O_WRONLY, 0644)
+	journalFile.Write([]byte(`{"seq":99,"command":"park KA-`))
+	journalFile.Close()
+
+	cmdInput := NewCommandInput().SnapshotFile(snapshotPath).JournalFile(journalPath)
+	recoveredSites := cmdInput.newParkingSites()
+	defer cmdInput.journal.Close()
+
+	expectedSites := NewParkingSitesWithClock(newMockClock())
+	expectedSites.Create(defaultParkingSiteName)
+	testSnapshotCommandsHelper(expectedSites, strings.Join(commands, "\n"))
+
+	expected, recovered := testComparableSnapshotHelper(expectedSites), testComparableSnapshotHelper(recoveredSites)
+	if !reflect.DeepEqual(recovered, expected) {
+		t.Errorf("Recovered parking lots should be\n%+v\nnot\n%+v", expected, recovered)
+	}
+}
+
+func TestJournalRecoverAfterKillWithSuccess(t *testing.T) {
+	testJournalRecoverAfterKillHelper(testJournalCommands, t)
+}
+
+func TestJournalRecoverAfterKillWithStrategies(t *testing.T) {
+	// Journal is compacted after leave and after park KA-04, so round robin and random continue from snapshot
+	testJournalRecoverAfterKillHelper([]string{
+		"create_parking_lot 6",
+		"allocation_strategy round_robin",
+		"park KA-01 White",
+		"park KA-02 Red",
+		"leave 1",
+		"park KA-03 Blue",
+		"create_lot mall",
+		"create_parking_lot 10",
+		"allocation_strategy random 7",
+		"park KA-04 Black",
+		"park KA-05 Black",
+		"use parking-lot",
+		"park KA-06 Grey",
+	}, t)
+}
+
+func TestOpenCommandJournalWithIncompleteEntry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "journal")
+	if err != nil {
+		t.Fatalf("Error should be empty")
+	}
+	defer os.RemoveAll(dir)
+	journalPath := filepath.Join(dir, "journal.log")
+	ioutil.WriteFile(journalPath, []byte(strings.Join([]string{
+		`{"seq":1,"lot":"parking-lot","command":"create_parking_lot 2"}`,
+		`{"seq":2,"lot":"parking-lot","command":"park KA-01 White"}`,
+		`{"seq":3,"lot":"parking-lot","comm`,
+	}, "\n")), 0644)
+
+	journal, err := OpenCommandJournal(journalPath, "", newMockClock())
+	if err != nil {
+		t.Fatalf("Journal should be opened (%s)", err.Error())
+	}
+	defer journal.Close()
+	if len(journal.entries) != 2 || journal.seqNo != 2 {
+		t.Errorf("Journal should have 2 complete entries not %d", len(journal.entries))
+	}
+
+	parkingSites := NewParkingSitesWithClock(journal.Clock())
+	parkingSites.Create(defaultParkingSiteName)
+	journal.Replay(parkingSites, 1)
+	parkingLot, _ := parkingSites.Get(defaultParkingSiteName)
+	if len(parkingLot.ParkingLot()) != 0 {
+		t.Errorf("Entry that included in snapshot should not be replayed")
+	}
+
+	journal.Run(parkingSites, defaultParkingSiteName, "create_parking_lot 1", nil, false, func() {})
+	data, _ := ioutil.ReadFile(journalPath)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[2], `{"seq":3,`) {
+		t.Errorf("New entry should be written after complete entries\n%s", string(data))
+	}
+}
+
+func TestJournalReplayLoadedFileWithSuccess(t *testing.T) {
+	dir, err := ioutil.TempDir("", "journal")
+	if err != nil {
+		t.Fatalf("Error should be empty")
+	}
+	defer os.RemoveAll(dir)
+	journalPath := filepath.Join(dir, "journal.log")
+	tariffPath := filepath.Join(dir, "tariff.json")
+	snapshotPath := filepath.Join(dir, "parking.json")
+	ioutil.WriteFile(tariffPath, []byte(`{"hourly_rate": 10}`), 0644)
+
+	journal, err := OpenCommandJournal(journalPath, "", newMockClock())
+	if err != nil {
+		t.Fatalf("Journal should be opened (%s)", err.Error())
+	}
+	parkingSites := NewParkingSitesWithClock(journal.Clock())
+	parkingLot, _ := parkingSites.Create(defaultParkingSiteName)
+	parkingCommand := newParkingLotCommandInput(strings.NewReader(strings.Join([]string{
+		"create_parking_lot 2",
+		"park KA-01 White",
+		"save " + snapshotPath,
+		"park KA-02 Red",
+		"load " + snapshotPath,
+		"load_tariff " + tariffPath,
+		"load_tariff " + filepath.Join(dir, "not-found.json"),
+	}, "\n")), ioutil.Discard, parkingSites, parkingLot)
+	parkingCommand.journal = journal
+	parkingCommand.start()
+	journal.Close()
+
+	// Files are changed after commands run, replay should use loaded content
+	ioutil.WriteFile(tariffPath, []byte(`{"hourly_rate": 99}`), 0644)
+	os.Remove(snapshotPath)
+
+	journal, err = OpenCommandJournal(journalPath, "", newMockClock())
+	if err != nil {
+		t.Fatalf("Journal should be opened (%s)", err.Error())
+	}
+	defer journal.Close()
+	if len(journal.entries) != 5 {
+		t.Errorf("Journal should have 5 entries not %d", len(journal.entries))
+	}
+	recoveredSites := NewParkingSitesWithClock(journal.Clock())
+	recoveredSites.Create(defaultParkingSiteName)
+	journal.Replay(recoveredSites, 0)
+
+	snapshot := recoveredSites.Snapshot()
+	if len(snapshot.Lots) != 1 || len(snapshot.Lots[0].Tickets) != 1 || snapshot.Lots[0].Tickets[0].RegistrationNumber != "KA-01" {
+		t.Errorf("Replayed parking lot should have KA-01 only from loaded snapshot")
+	}
+	if tariff := snapshot.Lots[0].Tariff; tariff == nil || tariff.HourlyRate == nil || *tariff.HourlyRate != 10 {
+		t.Errorf("Replayed tariff should be hourly rate 10 that was loaded")
+	}
+}
+
+func TestJournalRecoverAfterRestartWithCompaction(t *testing.T) {
+	dir, err := ioutil.TempDir("", "journal")
+	if err != nil {
+		t.Fatalf("Error should be empty")
+	}
+	defer os.RemoveAll(dir)
+	snapshotPath := filepath.Join(dir, "parking.json")
+	journalPath := filepath.Join(dir, "journal.log")
+
+	// runHelper is start with snapshot and journal, run commands then exit with compaction when isExit
+	runHelper := func(commands []string, isExit bool) IParkingSites {
+		cmdInput := NewCommandInput().SnapshotFile(snapshotPath).JournalFile(journalPath)
+		parkingSites := cmdInput.newParkingSites()
+		defer cmdInput.journal.Close()
+		parkingLot, _ := parkingSites.Get(defaultParkingSiteName)
+		parkingCommand := newParkingLotCommandInput(strings.NewReader(strings.Join(commands, "\n")), ioutil.Discard, parkingSites, parkingLot)
+		parkingCommand.journal = cmdInput.journal
+		parkingCommand.start()
+		if isExit {
+			cmdInput.saveParkingSites(parkingSites)
+		}
+		return parkingSites
+	}
+
+	runHelper([]string{"create_parking_lot 3", "park KA-01 White", "park KA-02 Red"}, true)
+	runHelper([]string{"park KA-03 Blue"}, false)
+	parkingSites := runHelper([]string{}, false)
+
+	parkingLot, _ := parkingSites.Get(defaultParkingSiteName)
+	if len(parkingLot.GetParkingLotsWithPlateNo("KA-03")) != 1 {
+		t.Errorf("KA-03 that was journaled after compaction should be recovered")
+	}
+	runHelper([]string{"park KA-04 Black"}, true)
+	parkingSites = runHelper([]string{}, false)
+	parkingLot, _ = parkingSites.Get(defaultParkingSiteName)
+	if len(parkingLot.GetParkingLotsWithPlateNo("KA-03")) != 1 || parkingLot.AvailableLotCount() != 0 {
+		t.Errorf("KA-03 and KA-04 should be recovered after second compaction")
+	}
+}
+
+func TestJournalFileCommandNotReadFromDisk(t *testing.T) {
+	dir, err := ioutil.TempDir("", "journal")
+	if err != nil {
+		t.Fatalf("Error should be empty")
+	}
+	defer os.RemoveAll(dir)
+	journalPath := filepath.Join(dir, "journal.log")
+	tariffPath := filepath.Join(dir, "tariff.json")
+	ioutil.WriteFile(tariffPath, []byte(`{"hourly_rate": 10}`), 0644)
+
+	// File command that is not allowed over network is not journaled
+	journal, err := OpenCommandJournal(journalPath, "", newMockClock())
+	if err != nil {
+		t.Fatalf("Journal should be opened (%s)", err.Error())
+	}
+	parkingSites := NewParkingSitesWithClock(journal.Clock())
+	parkingLot, _ := parkingSites.Create(defaultParkingSiteName)
+	parkingCommand := newParkingLotCommandInput(strings.NewReader("create_parking_lot 1\nload_tariff "+tariffPath), ioutil.Discard, parkingSites, parkingLot)
+	parkingCommand.journal = journal
+	parkingCommand.isFileCommandDisabled = true
+	parkingCommand.start()
+	if len(journal.entries) != 1 || parkingLot.Tariff() != nil {
+		t.Errorf("Rejected load_tariff should not be journaled or applied")
+	}
+	journal.Close()
+
+	// Entries without data and with empty data never read tariff file of path
+	journalFile, _ := os.OpenFile(journalPath, os.O_APPEND|os.O_WRONLY, 0644)
+	journalFile.Write([]byte(strings.Join([]string{
+		`{"seq":2,"lot":"parking-lot","command":"load_tariff ` + tariffPath + `"}`,
+		`{"seq":3,"lot":"parking-lot","command":"load_tariff ` + tariffPath + `","has_data":true}`,
+		"",
+	}, "\n")))
+	journalFile.Close()
+
+	journal, err = OpenCommandJournal(journalPath, "", newMockClock())
+	if err != nil {
+		t.Fatalf("Journal should be opened (%s)", err.Error())
+	}
+	defer journal.Close()
+	recoveredSites := NewParkingSitesWithClock(journal.Clock())
+	recoveredSites.Create(defaultParkingSiteName)
+	journal.Replay(recoveredSites, 0)
+	recoveredLot, _ := recoveredSites.Get(defaultParkingSiteName)
+	if recoveredLot.LotCount() != 1 || recoveredLot.Tariff() != nil {
+		t.Errorf("Replayed load_tariff should not read tariff file")
+	}
+}
+
+func TestJournalWithoutSnapshotNotCompacted(t *testing.T) {
+	dir, err := ioutil.TempDir("", "journal")
+	if err != nil {
+		t.Fatalf("Error should be empty")
+	}
+	defer os.RemoveAll(dir)
+	defer func(entries int) { journalCompactEntries = entries }(journalCompactEntries)
+	journalCompactEntries = 2
+
+	journal, err := OpenCommandJournal(filepath.Join(dir, "journal.log"), "", newMockClock())
+	if err != nil {
+		t.Fatalf("Journal should be opened (%s)", err.Error())
+	}
+	defer journal.Close()
+	parkingSites := NewParkingSitesWithClock(journal.Clock())
+	for i := 0; i < 3; i++ {
+		if err := journal.Run(parkingSites, defaultParkingSiteName, "create_parking_lot 1", nil, false, func() {}); err != nil {
+			t.Errorf("Run should be success (%s)", err.Error())
+		}
+	}
+	if len(journal.entries) != 3 || journal.seqNo != 3 {
+		t.Errorf("Journal without snapshot should keep 3 entries not %d", len(journal.entries))
+	}
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"fmt"
+	"parkinglot/errmsgs"
+	"strconv"
+)
+
+const (
+	// levelLotLabelFormat is label of lot in level e.g. L2-017
+	levelLotLabelFormat = "L%d-%03d"
+	// anyLevelNo is use for find lot without level filter
+	anyLevelNo = -1
+)
+
+// Level is keeping floor of parking lots
+type Level struct {
+	levelNo int
+	lotNos  []int
+}
+
+func newLevel(levelNo int) *Level {
+	return &Level{
+		levelNo: levelNo,
+		lotNos:  []int{},
+	}
+}
+
+// LevelNo is number of level
+func (level *Level) LevelNo() int {
+	return level.levelNo
+}
+
+// LotNos is all lot no in level
+func (level *Level) LotNos() []int {
+	return level.lotNos
+}
+
+// Label is lot label that show to user
+// Lot that created without level is show as lot no
+func (lot *ParkingLot) Label() string {
+	if lot.levelNo == 0 {
+		return strconv.Itoa(lot.lotNo)
+	}
+	return fmt.Sprintf(levelLotLabelFormat, lot.levelNo, lot.levelLotNo)
+}
+
+// LotLabels is label of each lot no, unknown lot no is show as lot no
+func (svc *Parking) LotLabels(lotNos []int) []string {
+	svc.mutex.RLock()
+	defer svc.mutex.RUnlock()
+
+	lotLabels := make([]string, 0, len(lotNos))
+	for _, lotNo := range lotNos {
+		if parkingLot, ok := svc.parkingLotKeyValue[lotNo]; ok {
+			lotLabels = append(lotLabels, parkingLot.Label())
+			continue
+		}
+		lotLabels = append(lotLabels, strconv.Itoa(lotNo))
+	}
+	return lotLabels
+}
+
+// Levels is all levels of parking
+func (svc *Parking) Levels() []*Level {
+	svc.mutex.RLock()
+	defer svc.mutex.RUnlock()
+
+	levels := make([]*Level, 0, len(svc.levels))
+	for _, level := range svc.levels {
+		levelSnapshot := newLevel(level.levelNo)
+		levelSnapshot.lotNos = append(levelSnapshot.lotNos, level.lotNos...)
+		levels = append(levels, levelSnapshot)
+	}
+	return levels
+}
+
+// CreateLevel is create new level with lot amount
+// Category ranges are lot no in level start from 1
+func (svc *Parking) CreateLevel(lotAmount int, categoryRanges ...LotCategoryRange) (bool, error) {
+	if lotAmount <= 0 {
+		return false, errmsgs.InternalServerError()
+	}
+
+	svc.mutex.Lock()
+	defer svc.unlock()
+
+	offset := len(svc.parkingLotKeyValue)
+	lotCategoryRanges := make([]LotCategoryRange, 0, len(categoryRanges))
+	for _, categoryRange := range categoryRanges {
+		categoryRange.From += offset
+		categoryRange.To += offset
+		lotCategoryRanges = append(lotCategoryRanges, categoryRange)
+	}
+
+	level := newLevel(len(svc.levels) + 1)
+	isCreated, err := svc.createParkingLots(level, lotAmount, lotCategoryRanges...)
+	if err != nil || !isCreated {
+		return isCreated, err
+	}
+
+	svc.levels = append(svc.levels, level)
+	return true, nil
+}
+
+// SetLevelPriority is set order of levels for find available lot
+// Levels that not in priority will be found after in level order
+func (svc *Parking) SetLevelPriority(levelNos []int) (bool, error) {
+	svc.mutex.Lock()
+	defer svc.unlock()
+
+	isDuplicated := map[int]bool{}
+	for _, levelNo := range levelNos {
+		if levelNo <= 0 || levelNo > len(svc.levels) || isDuplicated[levelNo] {
+			return false, errmsgs.LevelInvalidError()
+		}
+		isDuplicated[levelNo] = true
+	}
+
+	levelPriority := svc.levelPriority
+	svc.levelPriority = levelNos
+	if err := svc.save(nil, nil); err != nil {
+		svc.levelPriority = levelPriority
+		return false, err
+	}
+	return true, nil
+}
+
+// ParseLotNo is convert lot label (e.g. 17 or L2-017) to lot no
+func (svc *Parking) ParseLotNo(lotLabel string) (int, error) {
+	if lotNo, err := strconv.Atoi(lotLabel); err == nil {
+		return lotNo, nil
+	}
+
+	svc.mutex.RLock()
+	defer svc.mutex.RUnlock()
+
+	var levelNo, levelLotNo int
+	if _, err := fmt.Sscanf(lotLabel, "L%d-%d", &levelNo, &levelLotNo); err != nil {
+		return 0, errmsgs.LotNoInvalidError()
+	}
+	if levelNo <= 0 || levelNo > len(svc.levels) {
+		return 0, errmsgs.LotNoInvalidError()
+	}
+	level := svc.levels[levelNo-1]
+	if levelLotNo <= 0 || levelLotNo > len(level.lotNos) {
+		return 0, errmsgs.LotNoInvalidError()
+	}
+	return level.lotNos[levelLotNo-1], nil
+}
+
+// levelOrder is level nos in order for find available lot
+// Empty order is mean follow lot no
+func (svc *Parking) levelOrder() []int {
+	if len(svc.levelPriority) == 0 {
+		return []int{anyLevelNo}
+	}
+
+	isOrdered := map[int]bool{}
+	levelNos := []int{}
+	for _, levelNo := range svc.levelPriority {
+		levelNos = append(levelNos, levelNo)
+		isOrdered[levelNo] = true
+	}
+	// Lots that created without level is level 0
+	for levelNo := 0; levelNo <= len(svc.levels); levelNo++ {
+		if !isOrdered[levelNo] {
+			levelNos = append(levelNos, levelNo)
+		}
+	}
+	return levelNos
+}
+
+// formatLotNos is format lot nos to single lot or range of lots
+func (svc *Parking) formatLotNos(lotNos []int) string {
+	firstLot := svc.parkingLotKeyValue[lotNos[0]]
+	if len(lotNos) == 1 {
+		return firstLot.Label()
+	}
+
+	lastLot := svc.parkingLotKeyValue[lotNos[len(lotNos)-1]]
+	if lastLot.levelNo == 0 {
+		return fmt.Sprintf("%s-%d", firstLot.Label(), lastLot.lotNo)
+	}
+	return fmt.Sprintf("%s-%03d", firstLot.Label(), lastLot.levelLotNo)
+}
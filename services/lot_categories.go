@@ -0,0 +1,49 @@
+package services
+
+import (
+	"parkinglot/models"
+)
+
+// lotCategories is all lot categories in fallback order
+var lotCategories = []models.LotCategory{
+	models.CompactLot,
+	models.LargeLot,
+	models.MotorcycleLot,
+	models.ChargingLot,
+	models.DisabledLot,
+}
+
+// lotCategorySizes is lot size of each lot category
+var lotCategorySizes = map[models.LotCategory]float32{
+	models.CompactLot:    defaultLotSize,
+	models.LargeLot:      2,
+	models.ChargingLot:   defaultLotSize,
+	models.DisabledLot:   defaultLotSize,
+	models.MotorcycleLot: defaultLotSize,
+}
+
+// defaultLotPreferences is lot categories that each vehicle type can use
+// The first category is the most preferred
+func defaultLotPreferences() map[models.VehicleType][]models.LotCategory {
+	return map[models.VehicleType][]models.LotCategory{
+		models.Motorcycle:      {models.MotorcycleLot, models.CompactLot, models.LargeLot},
+		models.Car:             {models.CompactLot, models.LargeLot},
+		models.Truck:           {models.LargeLot, models.CompactLot},
+		models.Bus:             {models.LargeLot, models.CompactLot},
+		models.ElectricVehicle: {models.ChargingLot, models.CompactLot, models.LargeLot},
+		models.Accessible:      {models.DisabledLot, models.CompactLot, models.LargeLot},
+	}
+}
+
+// LotCategoryRange is category of lots from lot no to lot no
+type LotCategoryRange struct {
+	From     int
+	To       int
+	Category models.LotCategory
+}
+
+// isValidLotCategory is check lot category is support
+func isValidLotCategory(category models.LotCategory) bool {
+	_, ok := lotCategorySizes[category]
+	return ok
+}
@@ -2,10 +2,25 @@ package services
 
 import (
 	"fmt"
+	"io"
 	"parkinglot/errmsgs"
 	"parkinglot/models"
 	"sort"
 	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultLotSize is size of lot that create by CreateParkingLot
+	defaultLotSize float32 = 1
+	// reservedMarker is shown in status table for reserved lot
+	reservedMarker = "(reserved)"
+	// timeFormat is format of entry time that shown in status table
+	timeFormat = "2006-01-02 15:04:05"
+	// ticketIDPrefix is prefix of ticket id and ticketIDFormat is format of ticket id that issued when park
+	ticketIDPrefix = "T"
+	ticketIDFormat = ticketIDPrefix + "%06d"
 )
 
 // ParkingLotKeyValue is map key value ParkingLot
@@ -14,61 +29,301 @@ type ParkingLotKeyValue map[int]*ParkingLot
 // CarColorParkingLotKeyValue is map key value color and ParkingLots
 type CarColorParkingLotKeyValue map[int][]*ParkingLot
 
+// Allocation is keeping vehicle and all lots that vehicle use
+type Allocation struct {
+	ticketID string
+	vehicle  IVehicle
+	lotNos   []int
+
+	entryTime time.Time
+	// exitTime is zero value when vehicle still parking
+	exitTime time.Time
+	// fee is calculated by tariff when vehicle leave
+	fee float64
+}
+
+func newAllocation(ticketID string, vehicle IVehicle, lotNos []int, entryTime time.Time) *Allocation {
+	return &Allocation{
+		ticketID:  ticketID,
+		vehicle:   vehicle,
+		lotNos:    lotNos,
+		entryTime: entryTime,
+	}
+}
+
+// TicketID is id of ticket that issued when park
+func (alloc *Allocation) TicketID() string {
+	return alloc.ticketID
+}
+
+// Vehicle is vehicle of allocation
+func (alloc *Allocation) Vehicle() IVehicle {
+	return alloc.vehicle
+}
+
+// LotNos is all lot no that vehicle use
+func (alloc *Allocation) LotNos() []int {
+	return alloc.lotNos
+}
+
+// EntryTime is time that vehicle park
+func (alloc *Allocation) EntryTime() time.Time {
+	return alloc.entryTime
+}
+
+// ExitTime is time that vehicle leave, zero value when still parking
+func (alloc *Allocation) ExitTime() time.Time {
+	return alloc.exitTime
+}
+
+// Fee is parking fee that calculated when vehicle leave
+func (alloc *Allocation) Fee() float64 {
+	return alloc.fee
+}
+
+// snapshot is copy of allocation that safe to read after parking is unlocked
+func (alloc *Allocation) snapshot() *Allocation {
+	allocSnapshot := *alloc
+	return &allocSnapshot
+}
+
+// Duration is parking duration until vehicle leave
+// Vehicle that still parking will be zero duration
+func (alloc *Allocation) Duration() time.Duration {
+	if alloc.exitTime.IsZero() {
+		return 0
+	}
+	return alloc.exitTime.Sub(alloc.entryTime)
+}
+
 // ParkingLot is keeping parking lot data
 type ParkingLot struct {
-	lotNo   int
-	lotSize float32
-	status  models.ParkingStatus
-	vehicle IVehicle
+	lotNo    int
+	lotSize  float32
+	category models.LotCategory
+	status   models.ParkingStatus
+
+	// Level 0 is lot that created without level
+	levelNo    int
+	levelLotNo int
+
+	// Lot can keep many vehicles when vehicles smaller than lot size
+	allocations []*Allocation
+
+	reservedPlateNo string
 }
 
 func newParkingLot(lotNo int, lotSize float32) *ParkingLot {
 	return &ParkingLot{
-		lotNo:   lotNo,
-		lotSize: lotSize,
-		status:  models.Available,
-		vehicle: nil,
+		lotNo:       lotNo,
+		lotSize:     lotSize,
+		category:    models.CompactLot,
+		status:      models.Available,
+		allocations: nil,
+	}
+}
+
+// snapshot is copy of lot that safe to read after parking is unlocked
+func (lot *ParkingLot) snapshot() *ParkingLot {
+	lotSnapshot := *lot
+	lotSnapshot.allocations = nil
+	for _, alloc := range lot.allocations {
+		lotSnapshot.allocations = append(lotSnapshot.allocations, alloc.snapshot())
+	}
+	return &lotSnapshot
+}
+
+// snapshotLots is copy of lots that safe to read after parking is unlocked
+func snapshotLots(parkingLots []*ParkingLot) []*ParkingLot {
+	if parkingLots == nil {
+		return nil
+	}
+	lotSnapshots := make([]*ParkingLot, 0, len(parkingLots))
+	for _, parkingLot := range parkingLots {
+		lotSnapshots = append(lotSnapshots, parkingLot.snapshot())
+	}
+	return lotSnapshots
+}
+
+// Category is category of lot
+func (lot *ParkingLot) Category() models.LotCategory {
+	return lot.category
+}
+
+// Vehicles is all vehicles that park in lot
+func (lot *ParkingLot) Vehicles() []IVehicle {
+	vehicles := make([]IVehicle, 0, len(lot.allocations))
+	for _, alloc := range lot.allocations {
+		vehicles = append(vehicles, alloc.vehicle)
+	}
+	return vehicles
+}
+
+// usage is lot size that vehicles use in this lot
+func (lot *ParkingLot) usage() float32 {
+	var usage float32
+	for _, alloc := range lot.allocations {
+		usage += alloc.vehicle.UsageLot()
 	}
+	if usage > lot.lotSize {
+		return lot.lotSize
+	}
+	return usage
+}
+
+// isShareable is lot that keep only vehicles smaller than default lot size
+func (lot *ParkingLot) isShareable(vehicle IVehicle) bool {
+	if vehicle.UsageLot() >= defaultLotSize || !lot.hasSharedSpace() {
+		return false
+	}
+	return lot.usage()+vehicle.UsageLot() <= lot.lotSize
+}
+
+// hasSharedSpace is busy lot that keep only vehicles smaller than default lot size and still has space
+func (lot *ParkingLot) hasSharedSpace() bool {
+	if lot.status != models.Busy || len(lot.allocations) == 0 {
+		return false
+	}
+	for _, alloc := range lot.allocations {
+		if len(alloc.lotNos) > 1 || alloc.vehicle.UsageLot() >= defaultLotSize {
+			return false
+		}
+	}
+	return lot.usage() < lot.lotSize
+}
+
+// isInLevel is check lot is in level
+func (lot *ParkingLot) isInLevel(levelNo int) bool {
+	return levelNo == anyLevelNo || lot.levelNo == levelNo
+}
+
+// allocationOf is find allocation of vehicle in lot
+func (lot *ParkingLot) allocationOf(vehicle IVehicle) *Allocation {
+	for _, alloc := range lot.allocations {
+		if alloc.vehicle == vehicle {
+			return alloc
+		}
+	}
+	return nil
 }
 
 // IParking is parking interface
 type IParking interface {
 	Name() string
+	Clock() IClock
 
-	CreateParkingLot(lotAmount int) (bool, error)
+	CreateParkingLot(lotAmount int, categoryRanges ...LotCategoryRange) (bool, error)
+	CreateLevel(lotAmount int, categoryRanges ...LotCategoryRange) (bool, error)
+	SetLevelPriority(levelNos []int) (bool, error)
+	SetLotPreference(vehicleType models.VehicleType, categories []models.LotCategory) (bool, error)
+	SetAllocationStrategy(strategy IAllocationStrategy) error
+	AllocationStrategy() IAllocationStrategy
 
 	ParkingLot() ParkingLotKeyValue
+	ParkingLotWithNo(lotNo int) *ParkingLot
+	LotCount() int
+	Levels() []*Level
+	LotLabels(lotNos []int) []string
+	ParseLotNo(lotLabel string) (int, error)
 
 	GetAvailableLot() *ParkingLot
 	GetAllAvailableLotNos() []int
+	AvailableLotCount() int
 	GetParkingLotsWithCarColor(color string) []*ParkingLot
 	GetParkingLotsWithPlateNo(plateNo string) []*ParkingLot
+	GetParkingLotsWithVehicleType(vehicleType models.VehicleType) []*ParkingLot
+
+	GetHistoryWithPlateNo(plateNo string) []*Allocation
+	GetHistoryWithLotNo(lotNo int) []*Allocation
+	GetHistoryBetween(from, to time.Time) []*Allocation
 
 	Park(vehicle IVehicle) (*ParkingLot, error)
 	Leave(lotNo int) (bool, error)
+	LeaveVehicle(lotNo int, plateNo string) (*Allocation, error)
+	LeaveByTicket(ticketID string) (*Allocation, error)
+	LeaveByPlateNo(plateNo string) (*Allocation, error)
+
+	SetTariff(tariff ITariff) error
+	Tariff() ITariff
+
+	Reserve(lotNo int, plateNo string) (bool, error)
+	Unreserve(lotNo int) (bool, error)
 
-	IsSortAvailableLot() bool
+	BusyStatusTable(writer io.Writer, showEntryTime bool)
+	HistoryTable(writer io.Writer, allocs []*Allocation)
 
-	BusyStatusTable()
+	Subscribe(handler EventHandler) int
+	Unsubscribe(subscriptionID int) bool
+
+	Snapshot() ParkingSnapshot
 }
 
 // Parking is a set/get parking information
+// It is safe for concurrent use, returned lots and allocations are copies
 type Parking struct {
+	// mutex is lock all data of parking except name
+	mutex sync.RWMutex
+
 	name               string
 	parkingLotKeyValue ParkingLotKeyValue
 
-	availbleLotNos []int
+	// availableLots is all available lots and segmentLots is available lots of each category and level
+	availableLots IFreeLotAllocator
+	segmentLots   map[lotSegment]IFreeLotAllocator
+	// sharedLots is busy lots of each category and level that small vehicle may share
+	sharedLots     map[lotSegment]IFreeLotAllocator
+	reservedLotNos map[string]int
 
-	isSortAvailableLot bool
+	// plateAllocations is index of plate no and allocation that still parking
+	plateAllocations map[string]*Allocation
+	// colorAllocations is index of lower case color and allocations that still parking
+	colorAllocations map[string]map[*Allocation]struct{}
+	// tickets keep all issued tickets for check used ticket
+	tickets        map[string]*Allocation
+	ticketSeqNo    int
+	lotPreferences map[models.VehicleType][]models.LotCategory
+
+	levels        []*Level
+	levelPriority []int
+
+	clock    IClock
+	tariff   ITariff
+	strategy IAllocationStrategy
+
+	// subscribers are called after each change in subscribe order
+	subscribers       []eventSubscriber
+	subscriptionSeqNo int
+	// queuedEvents are sent after parking is unlocked, isSendingEvents is true while a caller send them
+	queuedEvents    []queuedEvent
+	isSendingEvents bool
+
+	// repository keep slots, vehicles and settings, nil is parking only in memory
+	repository IParkingRepository
+	// isDropped is true when parking sites dropped the parking, so vehicle is not parked with stale parking
+	isDropped bool
 }
 
 // NewParking is a now instant parking
 func NewParking(name string) IParking {
+	return NewParkingWithClock(name, NewSystemClock())
+}
+
+// NewParkingWithClock is a now instant parking that use clock for entry and exit time
+func NewParkingWithClock(name string, clock IClock) IParking {
 	return &Parking{
 		name:               name,
 		parkingLotKeyValue: map[int]*ParkingLot{},
-		availbleLotNos:     []int{},
-		isSortAvailableLot: true,
+		availableLots:      NewHeapFreeLots(),
+		segmentLots:        map[lotSegment]IFreeLotAllocator{},
+		sharedLots:         map[lotSegment]IFreeLotAllocator{},
+		reservedLotNos:     map[string]int{},
+		plateAllocations:   map[string]*Allocation{},
+		colorAllocations:   map[string]map[*Allocation]struct{}{},
+		tickets:            map[string]*Allocation{},
+		lotPreferences:     defaultLotPreferences(),
+		levels:             []*Level{},
+		clock:              clock,
+		strategy:           &nearestStrategy{},
 	}
 }
 
@@ -77,73 +332,248 @@ func (svc *Parking) Name() string {
 	return svc.name
 }
 
+// Clock is clock that parking use for entry and exit time
+func (svc *Parking) Clock() IClock {
+	return svc.clock
+}
+
 // ParkingLot is store data parking lots
 func (svc *Parking) ParkingLot() ParkingLotKeyValue {
-	return svc.parkingLotKeyValue
+	svc.mutex.RLock()
+	defer svc.mutex.RUnlock()
+
+	parkingLots := make(ParkingLotKeyValue, len(svc.parkingLotKeyValue))
+	for lotNo, parkingLot := range svc.parkingLotKeyValue {
+		parkingLots[lotNo] = parkingLot.snapshot()
+	}
+	return parkingLots
+}
+
+// ParkingLotWithNo is copy of one lot, nil if lot no is not in parking
+func (svc *Parking) ParkingLotWithNo(lotNo int) *ParkingLot {
+	svc.mutex.RLock()
+	defer svc.mutex.RUnlock()
+
+	parkingLot, ok := svc.parkingLotKeyValue[lotNo]
+	if !ok {
+		return nil
+	}
+	return parkingLot.snapshot()
+}
+
+// LotCount is amount of lots without copy them
+func (svc *Parking) LotCount() int {
+	svc.mutex.RLock()
+	defer svc.mutex.RUnlock()
+	return len(svc.parkingLotKeyValue)
 }
 
 // CreateParkingLot is create parking lot with amount
-func (svc *Parking) CreateParkingLot(lotAmount int) (bool, error) {
+// Lot that not in category ranges will be compact lot
+func (svc *Parking) CreateParkingLot(lotAmount int, categoryRanges ...LotCategoryRange) (bool, error) {
 	if lotAmount <= 0 {
 		return false, errmsgs.InternalServerError()
 	}
-	lotNo := len(svc.parkingLotKeyValue) + 1
-	for i := 0; i < lotAmount; i++ {
-		svc.parkingLotKeyValue[lotNo] = newParkingLot(lotNo, 1)
-		svc.availbleLotNos = append(svc.availbleLotNos, lotNo)
-		lotNo++
+
+	svc.mutex.Lock()
+	defer svc.unlock()
+	return svc.createParkingLots(nil, lotAmount, categoryRanges...)
+}
+
+// createParkingLots is local function for create lots in level
+// Nil level is create lots without level
+func (svc *Parking) createParkingLots(level *Level, lotAmount int, categoryRanges ...LotCategoryRange) (bool, error) {
+	firstLotNo := len(svc.parkingLotKeyValue) + 1
+	lastLotNo := firstLotNo + lotAmount - 1
+	for _, categoryRange := range categoryRanges {
+		if !isValidLotCategory(categoryRange.Category) {
+			return false, errmsgs.LotCategoryInvalidError()
+		}
+		if categoryRange.From < firstLotNo || categoryRange.To > lastLotNo || categoryRange.From > categoryRange.To {
+			return false, errmsgs.LotCategoryRangeInvalidError()
+		}
+	}
+
+	levelLotAmount := 0
+	if level != nil {
+		levelLotAmount = len(level.lotNos)
+	}
+	for lotNo := firstLotNo; lotNo <= lastLotNo; lotNo++ {
+		category := models.CompactLot
+		for _, categoryRange := range categoryRanges {
+			if lotNo >= categoryRange.From && lotNo <= categoryRange.To {
+				category = categoryRange.Category
+			}
+		}
+
+		parkingLot := newParkingLot(lotNo, lotCategorySizes[category])
+		parkingLot.category = category
+		if level != nil {
+			level.lotNos = append(level.lotNos, lotNo)
+			parkingLot.levelNo = level.levelNo
+			parkingLot.levelLotNo = len(level.lotNos)
+		}
+		svc.parkingLotKeyValue[lotNo] = parkingLot
+		svc.addAvailableLotNo(parkingLot)
+	}
+
+	lotNos := make([]int, 0, lotAmount)
+	for lotNo := firstLotNo; lotNo <= lastLotNo; lotNo++ {
+		lotNos = append(lotNos, lotNo)
+	}
+	if err := svc.save(lotNos, nil); err != nil {
+		for _, lotNo := range lotNos {
+			svc.removeAvailableLotNo(lotNo)
+			delete(svc.parkingLotKeyValue, lotNo)
+		}
+		if level != nil {
+			level.lotNos = level.lotNos[:levelLotAmount]
+		}
+		return false, err
+	}
+	svc.emit(svc.newEvent(models.LotCreatedEvent, lotNos))
+	return true, nil
+}
+
+// SetLotPreference is set lot categories that vehicle type can use
+// The first category is the most preferred
+func (svc *Parking) SetLotPreference(vehicleType models.VehicleType, categories []models.LotCategory) (bool, error) {
+	if _, ok := vehicleTypeUsageLots[vehicleType]; !ok {
+		return false, errmsgs.VehicleTypeInvalidError()
+	}
+	if len(categories) == 0 {
+		return false, errmsgs.LotCategoryInvalidError()
+	}
+	for _, category := range categories {
+		if !isValidLotCategory(category) {
+			return false, errmsgs.LotCategoryInvalidError()
+		}
+	}
+
+	svc.mutex.Lock()
+	defer svc.unlock()
+	preference, hasPreference := svc.lotPreferences[vehicleType]
+	svc.lotPreferences[vehicleType] = categories
+	if err := svc.save(nil, nil); err != nil {
+		if hasPreference {
+			svc.lotPreferences[vehicleType] = preference
+		} else {
+			delete(svc.lotPreferences, vehicleType)
+		}
+		return false, err
 	}
 	return true, nil
 }
 
+// SetAllocationStrategy is set way to choose lots for vehicle
+// Strategy is not changed when repository can not write it
+func (svc *Parking) SetAllocationStrategy(strategy IAllocationStrategy) error {
+	svc.mutex.Lock()
+	defer svc.unlock()
+
+	previousStrategy := svc.strategy
+	svc.strategy = strategy
+	if err := svc.save(nil, nil); err != nil {
+		svc.strategy = previousStrategy
+		return err
+	}
+	return nil
+}
+
+// AllocationStrategy is get way to choose lots for vehicle
+func (svc *Parking) AllocationStrategy() IAllocationStrategy {
+	svc.mutex.RLock()
+	defer svc.mutex.RUnlock()
+	return svc.strategy
+}
+
 // GetAvailableLot is a get available lot that nearest
 func (svc *Parking) GetAvailableLot() *ParkingLot {
-	availbleLotNos := svc.GetAllAvailableLotNos()
-	if len(availbleLotNos) == 0 {
-		return nil
-	}
+	svc.mutex.RLock()
+	defer svc.mutex.RUnlock()
 
-	if svc.IsSortAvailableLot() {
-		svc.isSortAvailableLot = false
-		sort.Ints(availbleLotNos)
+	nearestLot := svc.getNearestLot()
+	if nearestLot == nil {
+		return nil
 	}
-	nearestAvalLot := availbleLotNos[0]
-	return svc.ParkingLot()[nearestAvalLot]
+	return nearestLot.snapshot()
 }
 
-// GetAllAvailableLotNos is a get available lot that nearest
+// GetAllAvailableLotNos is a get all available lot nos that sorted by nearest
 func (svc *Parking) GetAllAvailableLotNos() []int {
-	return svc.availbleLotNos
+	svc.mutex.RLock()
+	defer svc.mutex.RUnlock()
+	return svc.availableLots.LotNos()
+}
+
+// AvailableLotCount is amount of available lots without list them
+func (svc *Parking) AvailableLotCount() int {
+	svc.mutex.RLock()
+	defer svc.mutex.RUnlock()
+	return svc.availableLots.Len()
 }
 
 // GetParkingLotsWithCarColor is a get all parking lot that color matches
+// Lots are sorted by lot no and lot that shared by same color vehicles will show once
 func (svc *Parking) GetParkingLotsWithCarColor(color string) []*ParkingLot {
-	lastLotNo := len(svc.parkingLotKeyValue)
-	var parkingLots []*ParkingLot
-	for i := 1; i <= lastLotNo; i++ {
-		if parkingLot, ok := svc.parkingLotKeyValue[i]; ok {
-			if parkingLot.vehicle == nil {
-				continue
-			}
-			if strings.ToLower(parkingLot.vehicle.Color()) == strings.ToLower(color) {
-				parkingLots = append(parkingLots, parkingLot)
+	svc.mutex.RLock()
+	defer svc.mutex.RUnlock()
+
+	allocs, ok := svc.colorAllocations[strings.ToLower(color)]
+	if !ok {
+		return nil
+	}
+
+	lotNos := []int{}
+	isAdded := map[int]bool{}
+	for alloc := range allocs {
+		for _, lotNo := range alloc.lotNos {
+			if !isAdded[lotNo] {
+				isAdded[lotNo] = true
+				lotNos = append(lotNos, lotNo)
 			}
 		}
 	}
+	sort.Ints(lotNos)
+
+	parkingLots := make([]*ParkingLot, 0, len(lotNos))
+	for _, lotNo := range lotNos {
+		parkingLots = append(parkingLots, svc.parkingLotKeyValue[lotNo].snapshot())
+	}
 	return parkingLots
 }
 
-// GetParkingLotsWithPlateNo is a get all vehicle that color[] matches
+// GetParkingLotsWithPlateNo is a get all parking lot that vehicle with plate no use
 func (svc *Parking) GetParkingLotsWithPlateNo(plateNo string) []*ParkingLot {
+	svc.mutex.RLock()
+	defer svc.mutex.RUnlock()
+
+	alloc, ok := svc.plateAllocations[plateNo]
+	if !ok {
+		return nil
+	}
+
+	parkingLots := make([]*ParkingLot, 0, len(alloc.lotNos))
+	for _, lotNo := range alloc.lotNos {
+		parkingLots = append(parkingLots, svc.parkingLotKeyValue[lotNo].snapshot())
+	}
+	return parkingLots
+}
+
+// GetParkingLotsWithVehicleType is a get all parking lot that vehicle type matches
+func (svc *Parking) GetParkingLotsWithVehicleType(vehicleType models.VehicleType) []*ParkingLot {
+	svc.mutex.RLock()
+	defer svc.mutex.RUnlock()
+
 	lastLotNo := len(svc.parkingLotKeyValue)
 	var parkingLots []*ParkingLot
 	for i := 1; i <= lastLotNo; i++ {
 		if parkingLot, ok := svc.parkingLotKeyValue[i]; ok {
-			if parkingLot.vehicle == nil {
-				continue
-			}
-			if parkingLot.vehicle.PlateNumber() == plateNo {
-				parkingLots = append(parkingLots, parkingLot)
+			for _, vehicle := range parkingLot.Vehicles() {
+				if vehicle.Type() == vehicleType {
+					parkingLots = append(parkingLots, parkingLot.snapshot())
+					break
+				}
 			}
 		}
 	}
@@ -151,104 +581,678 @@ func (svc *Parking) GetParkingLotsWithPlateNo(plateNo string) []*ParkingLot {
 }
 
 // Park is car park at lot
+// Vehicle with plate no that already parking can not park again
+// Vehicle that has reserved lot will park at that lot
+// Vehicle bigger than lot size will park at adjacent lots
+// Vehicle smaller than lot size will share lot with other small vehicles
+// The returned lot is the first lot of vehicle allocation
 func (svc *Parking) Park(vehicle IVehicle) (*ParkingLot, error) {
-	parkLot := svc.GetAvailableLot()
-	if parkLot == nil {
+	svc.mutex.Lock()
+	defer svc.unlock()
+
+	if svc.isDropped {
+		return nil, errmsgs.ParkingSiteNotFoundError()
+	}
+	if vehicle != nil {
+		if _, ok := svc.plateAllocations[vehicle.PlateNumber()]; ok {
+			return nil, errmsgs.VehicalAlreadyParkingError()
+		}
+	}
+
+	// Strategy position and lots are kept for undo park that repository did not write
+	strategy := snapshotStrategy(svc.strategy)
+	parkLots := svc.getAvailableLots(vehicle)
+	if len(parkLots) == 0 {
+		if vehicle != nil {
+			svc.emit(svc.newVehicleEvent(models.ParkRejectedFullEvent, nil, vehicle))
+		}
 		return nil, errmsgs.ParkingLotIsFullError()
 	}
 
-	updateParkLot := svc.parkingInLot(parkLot, vehicle)
+	lotStates := saveLotStates(parkLots)
+	ticketSeqNo := svc.ticketSeqNo
+	updateParkLot := svc.parkingInLot(parkLots, vehicle)
 	if !updateParkLot {
 		return nil, errmsgs.InternalServerError()
 	}
+	alloc := svc.plateAllocations[vehicle.PlateNumber()]
+	if err := svc.save(alloc.lotNos, []string{alloc.ticketID}); err != nil {
+		svc.restoreLotStates(lotStates)
+		svc.unindexAllocation(alloc)
+		delete(svc.tickets, alloc.ticketID)
+		svc.ticketSeqNo = ticketSeqNo
+		if restoredStrategy, err := restoreStrategy(strategy); err == nil {
+			svc.strategy = restoredStrategy
+		}
+		return nil, err
+	}
+	svc.emit(svc.newAllocationEvent(models.VehicleParkedEvent, alloc))
 
-	return parkLot, nil
+	return parkLots[0].snapshot(), nil
 }
 
 // Leave is car leave out of lot
+// All lots that vehicle use will be free
 func (svc *Parking) Leave(lotNo int) (bool, error) {
-	isLeaved := svc.leaveFromLot(lotNo)
-	if !isLeaved {
-		return false, errmsgs.VehicalNotParkingHereError()
+	alloc, err := svc.LeaveVehicle(lotNo, "")
+	return alloc != nil, err
+}
+
+// LeaveVehicle is vehicle with plate no leave out of lot
+// Plate no is required when lot is shared by many vehicles
+// The returned allocation has exit time, parking duration and fee
+func (svc *Parking) LeaveVehicle(lotNo int, plateNo string) (*Allocation, error) {
+	svc.mutex.Lock()
+	defer svc.unlock()
+
+	parkingLot, ok := svc.parkingLotKeyValue[lotNo]
+	if !ok || parkingLot == nil || len(parkingLot.allocations) == 0 {
+		return nil, errmsgs.VehicalNotParkingHereError()
+	}
+
+	var leaveAlloc *Allocation
+	for _, alloc := range parkingLot.allocations {
+		if plateNo == "" || alloc.vehicle.PlateNumber() == plateNo {
+			if leaveAlloc != nil {
+				return nil, errmsgs.VehicalPlateNoRequiredError()
+			}
+			leaveAlloc = alloc
+		}
 	}
-	return isLeaved, nil
+	if leaveAlloc == nil {
+		return nil, errmsgs.VehicalNotParkingHereError()
+	}
+
+	if err := svc.leaveFromLot(leaveAlloc); err != nil {
+		return nil, err
+	}
+	return leaveAlloc.snapshot(), nil
 }
 
-// IsSortAvailableLot is optimize for sort when needed
-func (svc *Parking) IsSortAvailableLot() bool {
-	return svc.isSortAvailableLot
+// LeaveByTicket is vehicle of ticket leave out of lot
+func (svc *Parking) LeaveByTicket(ticketID string) (*Allocation, error) {
+	svc.mutex.Lock()
+	defer svc.unlock()
+
+	alloc, ok := svc.tickets[ticketID]
+	if !ok {
+		return nil, errmsgs.TicketInvalidError()
+	}
+	if !alloc.exitTime.IsZero() {
+		return nil, errmsgs.TicketAlreadyUsedError()
+	}
+
+	if err := svc.leaveFromLot(alloc); err != nil {
+		return nil, err
+	}
+	return alloc.snapshot(), nil
 }
 
-// parkingInLot is local function for update park lot
-func (svc *Parking) parkingInLot(carPark *ParkingLot, vehicle IVehicle) bool {
-	if carPark == nil || vehicle == nil {
-		return false
+// LeaveByPlateNo is vehicle with plate no leave out of lot
+func (svc *Parking) LeaveByPlateNo(plateNo string) (*Allocation, error) {
+	svc.mutex.Lock()
+	defer svc.unlock()
+
+	alloc, ok := svc.plateAllocations[plateNo]
+	if !ok {
+		return nil, errmsgs.VehicalNotParkingHereError()
 	}
 
-	if carPark.vehicle != nil {
-		return false
+	if err := svc.leaveFromLot(alloc); err != nil {
+		return nil, err
 	}
+	return alloc.snapshot(), nil
+}
 
-	// If Lot not equal availble will not update
-	// Not support Reserve status yet
-	if carPark.status != models.Available {
-		return false
+// SetTariff is set tariff that calculate fee when vehicle leave
+// Nil tariff is mean parking is free
+// Tariff is not changed when repository can not write it
+func (svc *Parking) SetTariff(tariff ITariff) error {
+	svc.mutex.Lock()
+	defer svc.unlock()
+
+	previousTariff := svc.tariff
+	svc.tariff = tariff
+	if err := svc.save(nil, nil); err != nil {
+		svc.tariff = previousTariff
+		return err
 	}
+	return nil
+}
 
-	// Update available lot stores
-	// Require sort available parking lots if needed
-	indParkingLotNo := 0
-	for ind, lotNo := range svc.availbleLotNos {
-		if lotNo == carPark.lotNo {
-			indParkingLotNo = ind
-			if ind != 0 {
-				svc.isSortAvailableLot = true
-			}
-			break
-		}
+// Tariff is tariff of parking
+func (svc *Parking) Tariff() ITariff {
+	svc.mutex.RLock()
+	defer svc.mutex.RUnlock()
+	return svc.tariff
+}
+
+// Reserve is hold available lot for specific plate no
+func (svc *Parking) Reserve(lotNo int, plateNo string) (bool, error) {
+	if plateNo == "" {
+		return false, errmsgs.InternalServerError()
+	}
+
+	svc.mutex.Lock()
+	defer svc.unlock()
+
+	if svc.isDropped {
+		return false, errmsgs.ParkingSiteNotFoundError()
+	}
+	parkingLot, ok := svc.parkingLotKeyValue[lotNo]
+	if !ok || parkingLot == nil {
+		return false, errmsgs.ParkingLotNotAvailableError()
+	}
+
+	if parkingLot.status != models.Available || len(parkingLot.allocations) != 0 {
+		return false, errmsgs.ParkingLotNotAvailableError()
+	}
+
+	// Plate no that is parking or has reserved lot can not reserve other lot
+	if _, ok := svc.plateAllocations[plateNo]; ok {
+		return false, errmsgs.VehicalAlreadyParkingError()
 	}
-	copy(svc.availbleLotNos[indParkingLotNo:], svc.availbleLotNos[indParkingLotNo+1:])
-	svc.availbleLotNos[len(svc.availbleLotNos)-1] = -1
-	svc.availbleLotNos = svc.availbleLotNos[:len(svc.availbleLotNos)-1]
+	if _, ok := svc.reservedLotNos[plateNo]; ok {
+		return false, errmsgs.VehicalAlreadyParkingError()
+	}
+
+	lotStates := saveLotStates([]*ParkingLot{parkingLot})
+	svc.removeAvailableLotNo(lotNo)
+	svc.reservedLotNos[plateNo] = lotNo
 
 	// Update struct
-	carPark.vehicle = vehicle
-	carPark.status = models.Busy
+	parkingLot.status = models.Reserve
+	parkingLot.reservedPlateNo = plateNo
+	if err := svc.save([]int{lotNo}, nil); err != nil {
+		svc.restoreLotStates(lotStates)
+		return false, err
+	}
 
-	return true
+	event := svc.newEvent(models.LotReservedEvent, []int{lotNo})
+	event.RegistrationNumber = plateNo
+	svc.emit(event)
+	return true, nil
 }
 
-func (svc *Parking) leaveFromLot(lotNo int) bool {
+// Unreserve is release reserved lot back to available
+func (svc *Parking) Unreserve(lotNo int) (bool, error) {
+	svc.mutex.Lock()
+	defer svc.unlock()
+
 	parkingLot, ok := svc.parkingLotKeyValue[lotNo]
-	if !ok {
-		return false
+	if !ok || parkingLot == nil {
+		return false, errmsgs.ParkingLotNotReservedError()
 	}
 
-	if parkingLot == nil || parkingLot.vehicle == nil {
-		return false
+	if parkingLot.status != models.Reserve {
+		return false, errmsgs.ParkingLotNotReservedError()
 	}
 
-	svc.isSortAvailableLot = true
-	svc.availbleLotNos = append(svc.availbleLotNos, parkingLot.lotNo)
+	lotStates := saveLotStates([]*ParkingLot{parkingLot})
+	svc.addAvailableLotNo(parkingLot)
+	delete(svc.reservedLotNos, parkingLot.reservedPlateNo)
+	plateNo := parkingLot.reservedPlateNo
 
 	// Update struct
-	parkingLot.vehicle = nil
 	parkingLot.status = models.Available
-	svc.parkingLotKeyValue[lotNo] = parkingLot
+	parkingLot.reservedPlateNo = ""
+	if err := svc.save([]int{lotNo}, nil); err != nil {
+		svc.restoreLotStates(lotStates)
+		return false, err
+	}
+
+	event := svc.newEvent(models.LotUnreservedEvent, []int{lotNo})
+	event.RegistrationNumber = plateNo
+	svc.emit(event)
+	return true, nil
+}
+
+// getAvailableLots is local function for find lots that vehicle can park
+// Lot categories are tried by lot preference of vehicle type
+// and levels are tried by level priority in each category
+func (svc *Parking) getAvailableLots(vehicle IVehicle) []*ParkingLot {
+	if parkLot := svc.getReservedLot(vehicle); parkLot != nil {
+		return []*ParkingLot{parkLot}
+	}
+
+	if vehicle == nil || vehicle.UsageLot() <= 0 {
+		nearestLot := svc.getNearestLot()
+		if nearestLot == nil {
+			return nil
+		}
+		return []*ParkingLot{nearestLot}
+	}
+
+	categories := svc.lotCategoriesOf(vehicle)
+	levelNos := svc.strategy.LevelOrder(svc, svc.levelOrder())
+	for _, category := range categories {
+		for _, levelNo := range levelNos {
+			// Small vehicle will share busy lot first
+			if sharedLot := svc.getSharedLot(vehicle, category, levelNo); sharedLot != nil {
+				return []*ParkingLot{sharedLot}
+			}
+			if parkLots := svc.chooseAdjacentLots(vehicle.UsageLot(), category, levelNo); len(parkLots) != 0 {
+				return parkLots
+			}
+		}
+	}
+	return nil
+}
+
+// lotCategoriesOf is local function for lot categories that vehicle try in order
+// Vehicle that need charging try charging lots first, then lot preference of its vehicle type
+func (svc *Parking) lotCategoriesOf(vehicle IVehicle) []models.LotCategory {
+	categories, ok := svc.lotPreferences[vehicle.Type()]
+	if !ok {
+		categories = lotCategories
+	}
+
+	chargeableVehicle, ok := vehicle.(IChargeableVehicle)
+	if !ok || !chargeableVehicle.NeedCharging() {
+		return categories
+	}
+	chargingCategories := []models.LotCategory{models.ChargingLot}
+	for _, category := range categories {
+		if category != models.ChargingLot {
+			chargingCategories = append(chargingCategories, category)
+		}
+	}
+	return chargingCategories
+}
+
+// getNearestLot is local function for get available lot that nearest
+func (svc *Parking) getNearestLot() *ParkingLot {
+	nearestAvalLot, ok := svc.availableLots.Nearest()
+	if !ok {
+		return nil
+	}
+	return svc.parkingLotKeyValue[nearestAvalLot]
+}
+
+// getSharedLot is local function for find nearest busy lot that small vehicle can share
+func (svc *Parking) getSharedLot(vehicle IVehicle, category models.LotCategory, levelNo int) *ParkingLot {
+	if vehicle.UsageLot() >= defaultLotSize {
+		return nil
+	}
+
+	var nearestLot *ParkingLot
+	for segment, sharedLots := range svc.sharedLots {
+		if segment.category != category || (levelNo != anyLevelNo && segment.levelNo != levelNo) {
+			continue
+		}
+		sharedLots.Ascend(func(lotNo int) bool {
+			if nearestLot != nil && lotNo > nearestLot.lotNo {
+				return false
+			}
+			parkingLot := svc.parkingLotKeyValue[lotNo]
+			if !parkingLot.isShareable(vehicle) {
+				return true
+			}
+			nearestLot = parkingLot
+			return false
+		})
+	}
+	return nearestLot
+}
+
+// chooseAdjacentLots is local function for choose run of available lots by allocation strategy
+// Nearest strategy use free lot allocator without list all candidate runs
+func (svc *Parking) chooseAdjacentLots(usage float32, category models.LotCategory, levelNo int) []*ParkingLot {
+	if svc.strategy.Name() == models.NearestStrategy {
+		return svc.getAdjacentLots(usage, category, levelNo)
+	}
+	return svc.strategy.Choose(svc.getCandidateLots(usage, category, levelNo))
+}
+
+// getCandidateLots is local function for list all runs of available lots in category that fit usage
+// Runs are sorted by first lot no
+func (svc *Parking) getCandidateLots(usage float32, category models.LotCategory, levelNo int) [][]*ParkingLot {
+	candidates := [][]*ParkingLot{}
+	for segment, freeLots := range svc.segmentLots {
+		if segment.category != category || (levelNo != anyLevelNo && segment.levelNo != levelNo) {
+			continue
+		}
+		// Run of adjacent lots is slided, each first lot of run that fit usage is a candidate
+		var (
+			runLots []*ParkingLot
+			runSize float32
+		)
+		freeLots.Ascend(func(lotNo int) bool {
+			parkingLot := svc.parkingLotKeyValue[lotNo]
+			if len(runLots) != 0 && runLots[len(runLots)-1].lotNo+1 != lotNo {
+				runLots = nil
+				runSize = 0
+			}
+			runLots = append(runLots, parkingLot)
+			runSize += parkingLot.lotSize
+			for len(runLots) != 0 && runSize >= usage {
+				candidates = append(candidates, append([]*ParkingLot(nil), runLots...))
+				runSize -= runLots[0].lotSize
+				runLots = runLots[1:]
+			}
+			return true
+		})
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i][0].lotNo < candidates[j][0].lotNo
+	})
+	return candidates
+}
+
+// getAdjacentLots is local function for find nearest run of available lots in category that fit usage
+// Run of lots can not cross levels so each segment of category and level is searched alone
+// and the run that end first is the nearest
+func (svc *Parking) getAdjacentLots(usage float32, category models.LotCategory, levelNo int) []*ParkingLot {
+	var nearestRunLots []*ParkingLot
+	for segment, freeLots := range svc.segmentLots {
+		if segment.category != category || (levelNo != anyLevelNo && segment.levelNo != levelNo) {
+			continue
+		}
+		runLots := svc.getSegmentAdjacentLots(usage, freeLots)
+		if len(runLots) == 0 {
+			continue
+		}
+		if nearestRunLots == nil || runLots[len(runLots)-1].lotNo < nearestRunLots[len(nearestRunLots)-1].lotNo {
+			nearestRunLots = runLots
+		}
+	}
+	return nearestRunLots
+}
+
+// getSegmentAdjacentLots is local function for find nearest run of available lots in segment that fit usage
+// Vehicle that fit in one lot use nearest lot without scan all lots
+func (svc *Parking) getSegmentAdjacentLots(usage float32, freeLots IFreeLotAllocator) []*ParkingLot {
+	nearestLotNo, ok := freeLots.Nearest()
+	if !ok {
+		return nil
+	}
+	if nearestLot := svc.parkingLotKeyValue[nearestLotNo]; usage <= nearestLot.lotSize {
+		return []*ParkingLot{nearestLot}
+	}
+
+	var (
+		runLots    []*ParkingLot
+		runSize    float32
+		isRunFound bool
+	)
+	freeLots.Ascend(func(lotNo int) bool {
+		parkingLot := svc.parkingLotKeyValue[lotNo]
+		if len(runLots) == 0 || runLots[len(runLots)-1].lotNo+1 != lotNo {
+			runLots = []*ParkingLot{}
+			runSize = 0
+		}
+		runLots = append(runLots, parkingLot)
+		runSize += parkingLot.lotSize
+		isRunFound = runSize >= usage
+		return !isRunFound
+	})
+	if !isRunFound {
+		return nil
+	}
+	return runLots
+}
+
+// parkingInLot is local function for update park lot
+func (svc *Parking) parkingInLot(carParks []*ParkingLot, vehicle IVehicle) bool {
+	if len(carParks) == 0 || vehicle == nil {
+		return false
+	}
 
+	for _, carPark := range carParks {
+		if carPark == nil {
+			return false
+		}
+		if carPark.status == models.Reserve && carPark.reservedPlateNo != vehicle.PlateNumber() {
+			return false
+		}
+		if carPark.status == models.Busy && !carPark.isShareable(vehicle) {
+			return false
+		}
+	}
+
+	lotNos := make([]int, 0, len(carParks))
+	for _, carPark := range carParks {
+		lotNos = append(lotNos, carPark.lotNo)
+	}
+	svc.ticketSeqNo++
+	ticketID := fmt.Sprintf(ticketIDFormat, svc.ticketSeqNo)
+	alloc := newAllocation(ticketID, vehicle, lotNos, svc.clock.Now())
+	svc.tickets[ticketID] = alloc
+	svc.placeAllocation(carParks, alloc)
 	return true
 }
 
+// placeAllocation is local function for put allocation in its lots
+func (svc *Parking) placeAllocation(carParks []*ParkingLot, alloc *Allocation) {
+	svc.indexAllocation(alloc)
+
+	for _, carPark := range carParks {
+		// Available lot will remove from available lot stores
+		// Reserve lot only accept vehicle that reserved it
+		switch carPark.status {
+		case models.Available:
+			svc.removeAvailableLotNo(carPark.lotNo)
+		case models.Reserve:
+			delete(svc.reservedLotNos, carPark.reservedPlateNo)
+			carPark.reservedPlateNo = ""
+		}
+
+		// Update struct
+		carPark.allocations = append(carPark.allocations, alloc)
+		carPark.status = models.Busy
+		svc.updateSharedLotNo(carPark)
+	}
+}
+
+// getReservedLot is local function for get lot that reserved for vehicle
+// Vehicle bigger than reserved lot will park at other lots
+func (svc *Parking) getReservedLot(vehicle IVehicle) *ParkingLot {
+	if vehicle == nil {
+		return nil
+	}
+
+	lotNo, ok := svc.reservedLotNos[vehicle.PlateNumber()]
+	if !ok {
+		return nil
+	}
+	parkingLot := svc.parkingLotKeyValue[lotNo]
+	if parkingLot == nil || vehicle.UsageLot() > parkingLot.lotSize {
+		return nil
+	}
+	return parkingLot
+}
+
+// addAvailableLotNo is local function for add lot to available lot stores
+func (svc *Parking) addAvailableLotNo(parkingLot *ParkingLot) {
+	svc.availableLots.Release(parkingLot.lotNo)
+
+	segment := parkingLot.segmentOf()
+	if _, ok := svc.segmentLots[segment]; !ok {
+		svc.segmentLots[segment] = NewHeapFreeLots()
+	}
+	svc.segmentLots[segment].Release(parkingLot.lotNo)
+}
+
+// removeAvailableLotNo is local function for remove lot from available lot stores
+func (svc *Parking) removeAvailableLotNo(lotNo int) {
+	if !svc.availableLots.Acquire(lotNo) {
+		return
+	}
+	if parkingLot, ok := svc.parkingLotKeyValue[lotNo]; ok {
+		svc.segmentLots[parkingLot.segmentOf()].Acquire(lotNo)
+	}
+}
+
+// updateSharedLotNo is local function for add busy lot that small vehicle may share to shared lot stores
+// or remove it when it can not be shared, it is called after allocations or status of lot changed
+func (svc *Parking) updateSharedLotNo(parkingLot *ParkingLot) {
+	segment := parkingLot.segmentOf()
+	if _, ok := svc.sharedLots[segment]; !ok {
+		svc.sharedLots[segment] = NewHeapFreeLots()
+	}
+	if parkingLot.hasSharedSpace() {
+		svc.sharedLots[segment].Release(parkingLot.lotNo)
+		return
+	}
+	svc.sharedLots[segment].Acquire(parkingLot.lotNo)
+}
+
+// indexAllocation is local function for add allocation to plate and color indexes
+func (svc *Parking) indexAllocation(alloc *Allocation) {
+	svc.plateAllocations[alloc.vehicle.PlateNumber()] = alloc
+
+	color := strings.ToLower(alloc.vehicle.Color())
+	if _, ok := svc.colorAllocations[color]; !ok {
+		svc.colorAllocations[color] = map[*Allocation]struct{}{}
+	}
+	svc.colorAllocations[color][alloc] = struct{}{}
+}
+
+// unindexAllocation is local function for remove allocation from plate and color indexes
+func (svc *Parking) unindexAllocation(alloc *Allocation) {
+	delete(svc.plateAllocations, alloc.vehicle.PlateNumber())
+
+	color := strings.ToLower(alloc.vehicle.Color())
+	delete(svc.colorAllocations[color], alloc)
+	if len(svc.colorAllocations[color]) == 0 {
+		delete(svc.colorAllocations, color)
+	}
+}
+
+// leaveFromLot is local function for free all lots of allocation
+func (svc *Parking) leaveFromLot(alloc *Allocation) error {
+	if alloc == nil {
+		return errmsgs.VehicalNotParkingHereError()
+	}
+	parkingLots := make([]*ParkingLot, 0, len(alloc.lotNos))
+	for _, lotNo := range alloc.lotNos {
+		parkingLot, ok := svc.parkingLotKeyValue[lotNo]
+		if !ok || parkingLot == nil {
+			return errmsgs.VehicalNotParkingHereError()
+		}
+		parkingLots = append(parkingLots, parkingLot)
+	}
+
+	lotStates := saveLotStates(parkingLots)
+	alloc.exitTime = svc.clock.Now()
+	if svc.tariff != nil {
+		alloc.fee = svc.tariff.Fee(alloc.vehicle, parkingLots[0].category, alloc.entryTime, alloc.exitTime)
+	}
+	svc.unindexAllocation(alloc)
+
+	for _, parkingLot := range parkingLots {
+		allocations := parkingLot.allocations[:0]
+		for _, lotAlloc := range parkingLot.allocations {
+			if lotAlloc != alloc {
+				allocations = append(allocations, lotAlloc)
+			}
+		}
+		parkingLot.allocations = allocations
+		if len(parkingLot.allocations) != 0 {
+			svc.updateSharedLotNo(parkingLot)
+			continue
+		}
+
+		svc.addAvailableLotNo(parkingLot)
+
+		// Update struct
+		parkingLot.allocations = nil
+		parkingLot.status = models.Available
+		svc.updateSharedLotNo(parkingLot)
+	}
+
+	if err := svc.save(alloc.lotNos, []string{alloc.ticketID}); err != nil {
+		svc.restoreLotStates(lotStates)
+		svc.indexAllocation(alloc)
+		alloc.exitTime = time.Time{}
+		alloc.fee = 0
+		return err
+	}
+	svc.emit(svc.newAllocationEvent(models.VehicleLeftEvent, alloc))
+	return nil
+}
+
+// lotState is status of lot before change, it is put back when repository can not write the change
+type lotState struct {
+	parkingLot      *ParkingLot
+	status          models.ParkingStatus
+	reservedPlateNo string
+	allocations     []*Allocation
+}
+
+// saveLotStates is local function for keep state of lots before change
+func saveLotStates(parkingLots []*ParkingLot) []lotState {
+	lotStates := make([]lotState, 0, len(parkingLots))
+	for _, parkingLot := range parkingLots {
+		lotStates = append(lotStates, lotState{
+			parkingLot:      parkingLot,
+			status:          parkingLot.status,
+			reservedPlateNo: parkingLot.reservedPlateNo,
+			allocations:     append([]*Allocation(nil), parkingLot.allocations...),
+		})
+	}
+	return lotStates
+}
+
+// restoreLotStates is local function for put lots back to kept state with available lots and reservations
+func (svc *Parking) restoreLotStates(lotStates []lotState) {
+	for _, state := range lotStates {
+		parkingLot := state.parkingLot
+		if parkingLot.status == models.Reserve {
+			delete(svc.reservedLotNos, parkingLot.reservedPlateNo)
+		}
+		if parkingLot.status == models.Available && state.status != models.Available {
+			svc.removeAvailableLotNo(parkingLot.lotNo)
+		}
+		if parkingLot.status != models.Available && state.status == models.Available {
+			svc.addAvailableLotNo(parkingLot)
+		}
+
+		// Update struct
+		parkingLot.status = state.status
+		parkingLot.reservedPlateNo = state.reservedPlateNo
+		parkingLot.allocations = state.allocations
+		if parkingLot.status == models.Reserve {
+			svc.reservedLotNos[parkingLot.reservedPlateNo] = parkingLot.lotNo
+		}
+		svc.updateSharedLotNo(parkingLot)
+	}
+}
+
 // BusyStatusTable is format print string
-func (svc *Parking) BusyStatusTable() {
-	fmt.Printf("%-12s%-19s%s\n", "Slot No.", "Registration No", "Colour")
+// Reserved lot will show with reserved marker instead of colour
+// Vehicle that use many lots will show once with range of lots
+// Entry time column is shown when showEntryTime is true
+func (svc *Parking) BusyStatusTable(writer io.Writer, showEntryTime bool) {
+	svc.mutex.RLock()
+	defer svc.mutex.RUnlock()
+
+	if showEntryTime {
+		fmt.Fprintf(writer, "%-12s%-19s%-12s%s\n", "Slot No.", "Registration No", "Colour", "Parked Since")
+	} else {
+		fmt.Fprintf(writer, "%-12s%-19s%s\n", "Slot No.", "Registration No", "Colour")
+	}
 	lastLotNo := len(svc.parkingLotKeyValue)
 	for i := 1; i <= lastLotNo; i++ {
 		if parkingLot, ok := svc.parkingLotKeyValue[i]; ok {
-			if parkingLot.status != models.Busy || parkingLot.vehicle == nil {
+			if parkingLot.status == models.Reserve {
+				fmt.Fprintf(writer, "%-12s%-19s%s\n", parkingLot.Label(), parkingLot.reservedPlateNo, reservedMarker)
 				continue
 			}
-			fmt.Printf("%-12d%-19s%s\n", parkingLot.lotNo, parkingLot.vehicle.PlateNumber(), parkingLot.vehicle.Color())
+			if parkingLot.status != models.Busy {
+				continue
+			}
+			for _, alloc := range parkingLot.allocations {
+				if alloc.lotNos[0] != parkingLot.lotNo {
+					continue
+				}
+				if showEntryTime {
+					fmt.Fprintf(writer, "%-12s%-19s%-12s%s\n", svc.formatLotNos(alloc.lotNos), alloc.vehicle.PlateNumber(), alloc.vehicle.Color(), alloc.entryTime.Format(timeFormat))
+					continue
+				}
+				fmt.Fprintf(writer, "%-12s%-19s%s\n", svc.formatLotNos(alloc.lotNos), alloc.vehicle.PlateNumber(), alloc.vehicle.Color())
+			}
 		}
 	}
 }
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"bytes"
+	"parkinglot/errmsgs"
+	"parkinglot/models"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testParkingConformanceHelper is behaviour that every IParking implementation must have
+func testParkingConformanceHelper(newParking func(name string, clock IClock) IParking, t *testing.T) {
+	clock := newMockClock()
+	parking := newParking("conformance", clock)
+	if parking.Name() != "conformance" {
+		t.Errorf("Name should be conformance")
+	}
+
+	if _, err := parking.Park(NewCar("KA-00", "White", 1)); !(err != nil && err.Error() == errmsgs.ParkingLotIsFullError().Error()) {
+		t.Errorf("Error should have and should be parking lot is full")
+	}
+	if isCreated, err := parking.CreateLevel(3, LotCategoryRange{From: 3, To: 3, Category: models.LargeLot}); !isCreated || err != nil {
+		t.Errorf("Level should be created")
+	}
+	if isCreated, err := parking.CreateParkingLot(3); !isCreated || err != nil {
+		t.Errorf("Parking lot should be created")
+	}
+	if len(parking.ParkingLot()) != 6 || len(parking.Levels()) != 1 {
+		t.Errorf("Parking should have 6 lots and 1 level")
+	}
+
+	parking.SetTariff(testTariffHelper(10))
+	if isReserved, err := parking.Reserve(6, "KA-09"); !isReserved || err != nil {
+		t.Errorf("Lot 6 should be reserved")
+	}
+
+	car, _ := NewVehicle(models.Car, "KA-01", "White", VehicleAttributes{})
+	truck, _ := NewVehicle(models.Truck, "KA-02", "Red", VehicleAttributes{})
+	firstMotorcycle, _ := NewVehicle(models.Motorcycle, "KA-03", "Black", VehicleAttributes{})
+	secondMotorcycle, _ := NewVehicle(models.Motorcycle, "KA-04", "Black", VehicleAttributes{})
+	for _, vehicle := range []IVehicle{car, truck, firstMotorcycle, secondMotorcycle} {
+		if _, err := parking.Park(vehicle); err != nil {
+			t.Errorf("Vehicle %s should be parking (%s)", vehicle.PlateNumber(), err.Error())
+		}
+	}
+	if _, err := parking.Park(car); !(err != nil && err.Error() == errmsgs.VehicalAlreadyParkingError().Error()) {
+		t.Errorf("Error should have and should be vehical already parking")
+	}
+
+	testCases := []struct {
+		name     string
+		lots     []*ParkingLot
+		expected string
+	}{
+		{"white", parking.GetParkingLotsWithCarColor("WHITE"), "L1-001"},
+		{"black", parking.GetParkingLotsWithCarColor("black"), "L1-002"},
+		{"KA-02", parking.GetParkingLotsWithPlateNo("KA-02"), "L1-003"},
+		{"motorcycle", parking.GetParkingLotsWithVehicleType(models.Motorcycle), "L1-002"},
+	}
+	for _, testCase := range testCases {
+		lotNos := []int{}
+		for _, parkingLot := range testCase.lots {
+			lotNos = append(lotNos, parkingLot.lotNo)
+		}
+		if lotLabels := strings.Join(parking.LotLabels(lotNos), ","); lotLabels != testCase.expected {
+			t.Errorf("Lots of %s should be %s not %s", testCase.name, testCase.expected, lotLabels)
+		}
+	}
+	if strings.Join(parking.LotLabels(parking.GetAllAvailableLotNos()), ",") != "4,5" {
+		t.Errorf("Available lots should be 4,5")
+	}
+
+	clock.add(90 * time.Minute)
+	alloc, err := parking.LeaveByTicket("T000001")
+	if err != nil || alloc.Fee() != 20 || alloc.Duration() != 90*time.Minute {
+		t.Errorf("Vehicle of ticket T000001 should leave with fee 20")
+	}
+	if _, err := parking.LeaveByTicket("T000001"); !(err != nil && err.Error() == errmsgs.TicketAlreadyUsedError().Error()) {
+		t.Errorf("Error should have and should be ticket was already used")
+	}
+	if _, err := parking.LeaveVehicle(2, ""); !(err != nil && err.Error() == errmsgs.VehicalPlateNoRequiredError().Error()) {
+		t.Errorf("Error should have and should be plate no required for shared lot")
+	}
+	if alloc, err := parking.LeaveVehicle(2, "KA-04"); err != nil || alloc.TicketID() != "T000004" {
+		t.Errorf("Vehicle KA-04 should leave from shared lot")
+	}
+	if alloc, err := parking.LeaveByPlateNo("KA-02"); err != nil || alloc.Fee() != 20 {
+		t.Errorf("Vehicle KA-02 should leave with fee 20")
+	}
+	if isLeft, err := parking.Leave(2); !isLeft || err != nil {
+		t.Errorf("Vehicle at lot 2 should leave")
+	}
+
+	if isUnreserved, err := parking.Unreserve(6); !isUnreserved || err != nil {
+		t.Errorf("Lot 6 should be unreserved")
+	}
+	if _, err := parking.Unreserve(6); !(err != nil && err.Error() == errmsgs.ParkingLotNotReservedError().Error()) {
+		t.Errorf("Error should have and should be parking lot not reserved")
+	}
+	if len(parking.GetAllAvailableLotNos()) != 6 {
+		t.Errorf("All lots should be available")
+	}
+
+	parking.Park(NewCar("KA-05", "Grey", 1))
+	status := &bytes.Buffer{}
+	parking.BusyStatusTable(status, false)
+	if status.String() != "Slot No.    Registration No    Colour\nL1-001      KA-05              Grey\n" {
+		t.Errorf("Status should have KA-05 at L1-001 not\n%s", status.String())
+	}
+}
+
+// testTariffHelper is tariff with hourly rate
+func testTariffHelper(hourlyRate float64) ITariff {
+	tariff, _ := NewTariff(TariffConfig{TariffRule: TariffRule{HourlyRate: &hourlyRate}})
+	return tariff
+}
+
+func TestParkingConformanceWithMemory(t *testing.T) {
+	testParkingConformanceHelper(NewParkingWithClock, t)
+}
+
+// testStorageReopenHelper is parkings that are changed in storage then loaded by reopened storage
+func testStorageReopenHelper(storage IParkingStorage, reopenStorage func() IParkingStorage, t *testing.T) {
+	clock := newMockClock()
+	parkingSites, _ := NewParkingSitesWithStorage(storage, clock)
+	parking, _ := parkingSites.Create(defaultParkingSiteName)
+	parking.CreateLevel(2)
+	parking.CreateParkingLot(2, LotCategoryRange{From: 3, To: 3, Category: models.LargeLot})
+	parking.SetLevelPriority([]int{1})
+	parking.SetTariff(testTariffHelper(10))
+	strategy, _ := NewAllocationStrategy(models.RandomStrategy, StrategyAttributes{Seed: 7})
+	parking.SetAllocationStrategy(strategy)
+	parking.Reserve(4, "KA-09")
+	parking.Park(NewCar("KA-01", "White", 1))
+	parking.Park(NewCar("KA-02", "Red", 1))
+	clock.add(time.Hour)
+	parking.LeaveByPlateNo("KA-01")
+	parkingSites.Create("mall")
+	parkingSites.Create("airport")
+	parkingSites.Drop("airport")
+
+	reopenedStorage := reopenStorage()
+	reopenedSites, err := NewParkingSitesWithStorage(reopenedStorage, clock)
+	if err != nil {
+		t.Fatalf("Parking sites should be loaded (%s)", err.Error())
+	}
+
+	if !reflect.DeepEqual(reopenedSites.Snapshot(), parkingSites.Snapshot()) {
+		t.Errorf("Reopened parking lots should be\n%+v\nnot\n%+v", parkingSites.Snapshot(), reopenedSites.Snapshot())
+	}
+	if names, _ := reopenedStorage.Names(); strings.Join(names, ",") != "parking-lot,mall" {
+		t.Errorf("Stored parking lots should be parking-lot,mall")
+	}
+}
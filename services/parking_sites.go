@@ -0,0 +1,190 @@
+package services
+
+import (
+	"parkinglot/errmsgs"
+	"sync"
+)
+
+// IParkingSites is named parkings interface
+type IParkingSites interface {
+	Create(name string) (IParking, error)
+	Drop(name string) (bool, error)
+
+	Get(name string) (IParking, error)
+	Parkings() []IParking
+
+	GetParkingsWithPlateNo(plateNo string) []IParking
+
+	Snapshot() ParkingSitesSnapshot
+	Restore(snapshot ParkingSitesSnapshot) error
+}
+
+// IParkingStorage is storage that keep parkings outside of process
+type IParkingStorage interface {
+	// Names is names of stored parkings in created order
+	Names() ([]string, error)
+	// Open is load stored parking or create new one when name is not stored
+	Open(name string, clock IClock) (IParking, error)
+	// Remove is remove stored parking
+	Remove(name string) error
+}
+
+// ParkingSites is keeping many named parkings in one process
+// It is safe for concurrent use
+type ParkingSites struct {
+	mutex sync.RWMutex
+
+	parkings  map[string]IParking
+	siteNames []string
+
+	clock IClock
+	// storage keep parkings, nil is parkings only in memory
+	storage IParkingStorage
+}
+
+// NewParkingSites is a new instant parking sites
+func NewParkingSites() IParkingSites {
+	return NewParkingSitesWithClock(NewSystemClock())
+}
+
+// NewParkingSitesWithClock is a new instant parking sites that all parkings use clock
+func NewParkingSitesWithClock(clock IClock) IParkingSites {
+	return &ParkingSites{
+		parkings:  map[string]IParking{},
+		siteNames: []string{},
+		clock:     clock,
+	}
+}
+
+// NewParkingSitesWithStorage is a new parking sites that load and keep all parkings in storage
+func NewParkingSitesWithStorage(storage IParkingStorage, clock IClock) (IParkingSites, error) {
+	names, err := storage.Names()
+	if err != nil {
+		return nil, err
+	}
+
+	parkingSites := &ParkingSites{
+		parkings:  map[string]IParking{},
+		siteNames: []string{},
+		clock:     clock,
+		storage:   storage,
+	}
+	for _, name := range names {
+		parking, err := storage.Open(name, clock)
+		if err != nil {
+			return nil, err
+		}
+		parkingSites.parkings[name] = parking
+		parkingSites.siteNames = append(parkingSites.siteNames, name)
+	}
+	return parkingSites, nil
+}
+
+// newParking is local function for new parking in storage or in memory
+func (svc *ParkingSites) newParking(name string) (IParking, error) {
+	if svc.storage == nil {
+		return NewParkingWithClock(name, svc.clock), nil
+	}
+	return svc.storage.Open(name, svc.clock)
+}
+
+// Create is create new parking with unique name
+func (svc *ParkingSites) Create(name string) (IParking, error) {
+	if name == "" {
+		return nil, errmsgs.InternalServerError()
+	}
+
+	svc.mutex.Lock()
+	defer svc.mutex.Unlock()
+	if _, ok := svc.parkings[name]; ok {
+		return nil, errmsgs.ParkingSiteAlreadyExistError()
+	}
+
+	parking, err := svc.newParking(name)
+	if err != nil {
+		return nil, err
+	}
+	svc.parkings[name] = parking
+	svc.siteNames = append(svc.siteNames, name)
+	return parking, nil
+}
+
+// Drop is remove parking that has no vehicle
+func (svc *ParkingSites) Drop(name string) (bool, error) {
+	svc.mutex.Lock()
+	defer svc.mutex.Unlock()
+
+	parking, ok := svc.parkings[name]
+	if !ok {
+		return false, errmsgs.ParkingSiteNotFoundError()
+	}
+	// Parking is locked from empty check until it is removed, so vehicle is not parked in between
+	if dropParking, ok := parking.(*Parking); ok {
+		dropParking.mutex.Lock()
+		defer dropParking.mutex.Unlock()
+
+		for _, parkingLot := range dropParking.parkingLotKeyValue {
+			if len(parkingLot.allocations) != 0 {
+				return false, errmsgs.ParkingSiteNotEmptyError()
+			}
+		}
+	} else {
+		for _, parkingLot := range parking.ParkingLot() {
+			if len(parkingLot.allocations) != 0 {
+				return false, errmsgs.ParkingSiteNotEmptyError()
+			}
+		}
+	}
+	if svc.storage != nil {
+		if err := svc.storage.Remove(name); err != nil {
+			return false, err
+		}
+	}
+	if dropParking, ok := parking.(*Parking); ok {
+		dropParking.isDropped = true
+	}
+
+	delete(svc.parkings, name)
+	for ind, siteName := range svc.siteNames {
+		if siteName == name {
+			svc.siteNames = append(svc.siteNames[:ind], svc.siteNames[ind+1:]...)
+			break
+		}
+	}
+	return true, nil
+}
+
+// Get is get parking by name
+func (svc *ParkingSites) Get(name string) (IParking, error) {
+	svc.mutex.RLock()
+	defer svc.mutex.RUnlock()
+
+	parking, ok := svc.parkings[name]
+	if !ok {
+		return nil, errmsgs.ParkingSiteNotFoundError()
+	}
+	return parking, nil
+}
+
+// Parkings is all parkings in created order
+func (svc *ParkingSites) Parkings() []IParking {
+	svc.mutex.RLock()
+	defer svc.mutex.RUnlock()
+
+	parkings := make([]IParking, 0, len(svc.siteNames))
+	for _, name := range svc.siteNames {
+		parkings = append(parkings, svc.parkings[name])
+	}
+	return parkings
+}
+
+// GetParkingsWithPlateNo is get all parkings that vehicle with plate no is parking
+func (svc *ParkingSites) GetParkingsWithPlateNo(plateNo string) []IParking {
+	var parkings []IParking
+	for _, parking := range svc.Parkings() {
+		if len(parking.GetParkingLotsWithPlateNo(plateNo)) != 0 {
+			parkings = append(parkings, parking)
+		}
+	}
+	return parkings
+}
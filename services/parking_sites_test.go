@@ -0,0 +1,195 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"parkinglot/errmsgs"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestCreateParkingSiteWithInputError(t *testing.T) {
+	parkingSites := NewParkingSites()
+
+	parking, err := parkingSites.Create("")
+	if parking != nil {
+		t.Errorf("Parking should be empty")
+	}
+	if !(err != nil && err.Error() == errmsgs.InternalServerError().Error()) {
+		t.Errorf("Error should have and should be internal server error")
+	}
+
+	if _, err := parkingSites.Create("downtown"); err != nil {
+		t.Errorf("Error should be empty")
+	}
+	parking, err = parkingSites.Create("downtown")
+	if parking != nil {
+		t.Errorf("Parking should be empty")
+	}
+	if !(err != nil && err.Error() == errmsgs.ParkingSiteAlreadyExistError().Error()) {
+		t.Errorf("Error should have and should be parking site already exist")
+	}
+
+	parking, err = parkingSites.Get("airport")
+	if parking != nil {
+		t.Errorf("Parking should be empty")
+	}
+	if !(err != nil && err.Error() == errmsgs.ParkingSiteNotFoundError().Error()) {
+		t.Errorf("Error should have and should be parking site not found")
+	}
+}
+
+func TestCreateParkingSiteWithSuccess(t *testing.T) {
+	parkingSites := NewParkingSites()
+
+	names := []string{"downtown", "airport", "mall"}
+	for _, name := range names {
+		parking, err := parkingSites.Create(name)
+		if err != nil || parking == nil {
+			t.Errorf("Parking %s should be created", name)
+		}
+	}
+
+	parkings := parkingSites.Parkings()
+	if len(parkings) != len(names) {
+		t.Errorf("Parkings should be %d", len(names))
+	}
+	for ind, parking := range parkings {
+		if parking.Name() != names[ind] {
+			t.Errorf("Parking name should be %s", names[ind])
+		}
+	}
+
+	parking, err := parkingSites.Get("airport")
+	if err != nil || parking == nil || parking.Name() != "airport" {
+		t.Errorf("Parking airport should be found")
+	}
+}
+
+func TestDropParkingSiteWithVehicle(t *testing.T) {
+	parkingSites := NewParkingSites()
+
+	isDropped, err := parkingSites.Drop("downtown")
+	if isDropped || !(err != nil && err.Error() == errmsgs.ParkingSiteNotFoundError().Error()) {
+		t.Errorf("Error should have and should be parking site not found")
+	}
+
+	parking, _ := parkingSites.Create("downtown")
+	parking.CreateParkingLot(2)
+	parking.Park(NewCar("plate-1", "red", 1))
+
+	isDropped, err = parkingSites.Drop("downtown")
+	if isDropped || !(err != nil && err.Error() == errmsgs.ParkingSiteNotEmptyError().Error()) {
+		t.Errorf("Error should have and should be parking site not empty")
+	}
+
+	parking.Leave(1)
+	isDropped, err = parkingSites.Drop("downtown")
+	if !isDropped || err != nil {
+		t.Errorf("Parking should be dropped")
+	}
+	if len(parkingSites.Parkings()) != 0 {
+		t.Errorf("Parkings should be empty")
+	}
+}
+
+func TestDropParkingSiteNotParkWithDroppedParking(t *testing.T) {
+	parkingSites := NewParkingSites()
+
+	parking, _ := parkingSites.Create("downtown")
+	parking.CreateParkingLot(2)
+	if isDropped, err := parkingSites.Drop("downtown"); !isDropped || err != nil {
+		t.Errorf("Parking should be dropped")
+	}
+
+	parkingLot, err := parking.Park(NewCar("plate-1", "red", 1))
+	if parkingLot != nil || !(err != nil && err.Error() == errmsgs.ParkingSiteNotFoundError().Error()) {
+		t.Errorf("Error should have and should be parking site not found")
+	}
+	isReserved, err := parking.Reserve(2, "plate-2")
+	if isReserved || !(err != nil && err.Error() == errmsgs.ParkingSiteNotFoundError().Error()) {
+		t.Errorf("Error should have and should be parking site not found")
+	}
+}
+
+func TestDropParkingSiteWithOtherSession(t *testing.T) {
+	parkingSites := NewParkingSites()
+	responses := &bytes.Buffer{}
+	session := newParkingLotCommandInput(strings.NewReader(""), responses, parkingSites, nil)
+	otherSession := newParkingLotCommandInput(strings.NewReader(""), ioutil.Discard, parkingSites, nil)
+
+	session.runCommand("create_lot mall")
+	session.runCommand("create_parking_lot 2")
+	otherSession.runCommand("use mall")
+	session.runCommand("drop_lot mall")
+
+	responses.Reset()
+	otherSession.writer = responses
+	otherSession.runCommand("park KA-01 White")
+	if !strings.Contains(responses.String(), "Please select parking lot with use command") {
+		t.Errorf("Park should be rejected with dropped parking lot not %s", responses.String())
+	}
+
+	session.runCommand("create_lot mall")
+	session.runCommand("create_parking_lot 1")
+	otherSession.runCommand("park KA-01 White")
+	mall, _ := parkingSites.Get("mall")
+	if parkingLot := mall.ParkingLotWithNo(1); parkingLot == nil || len(parkingLot.allocations) != 1 {
+		t.Errorf("Vehicle should be parked at parking lot created again")
+	}
+}
+
+func TestGetParkingsWithPlateNoWithSuccessData(t *testing.T) {
+	parkingSites := NewParkingSites()
+
+	for _, name := range []string{"downtown", "airport", "mall"} {
+		parking, _ := parkingSites.Create(name)
+		parking.CreateParkingLot(2)
+	}
+	downtown, _ := parkingSites.Get("downtown")
+	downtown.Park(NewCar("plate-1", "red", 1))
+	mall, _ := parkingSites.Get("mall")
+	mall.Park(NewCar("plate-2", "red", 1))
+	mall.Park(NewCar("plate-1", "red", 1))
+
+	parkings := parkingSites.GetParkingsWithPlateNo("plate-1")
+	if len(parkings) != 2 || parkings[0].Name() != "downtown" || parkings[1].Name() != "mall" {
+		t.Errorf("Plate no should be found at downtown and mall")
+	}
+
+	parkings = parkingSites.GetParkingsWithPlateNo("plate-3")
+	if parkings != nil || len(parkings) > 0 {
+		t.Errorf("Parkings should be empty")
+	}
+}
+
+func TestParkingSitesConcurrently(t *testing.T) {
+	parkingSites := NewParkingSites()
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < 20; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			name := fmt.Sprintf("site-%d", worker%5)
+			parking, err := parkingSites.Create(name)
+			if err != nil {
+				parking, err = parkingSites.Get(name)
+			}
+			if err != nil || parking == nil {
+				t.Errorf("Parking %s should be found", name)
+				return
+			}
+			parking.CreateParkingLot(1)
+			parking.Park(NewCar(fmt.Sprintf("plate-%d", worker), "red", 1))
+			parkingSites.GetParkingsWithPlateNo(fmt.Sprintf("plate-%d", worker))
+		}(worker)
+	}
+	wg.Wait()
+
+	if len(parkingSites.Parkings()) != 5 {
+		t.Errorf("Parkings should be 5")
+	}
+}
@@ -1,9 +1,14 @@
 package services
 
 import (
+	"fmt"
+	"io/ioutil"
 	"parkinglot/errmsgs"
 	"parkinglot/models"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestInputOutputNameMustValid(t *testing.T) {
@@ -35,8 +40,8 @@ func TestNameParkingAllFieldsMustBeEmpty(t *testing.T) {
 		t.Errorf("Available lot should be empty")
 	}
 
-	if !parking.IsSortAvailableLot() {
-		t.Errorf("IsSort should be true")
+	if parking.AvailableLotCount() != 0 {
+		t.Errorf("Available lot count should be 0")
 	}
 }
 
@@ -353,7 +358,11 @@ func TestGetParkingLotsWithPlatNoWithCarInParkingLotSuccessData(t *testing.T) {
 	if parkLots == nil || len(parkLots) == 0 {
 		t.Errorf("Parking lot should not empty")
 	}
-	if len(parkLots) != 30 {
+	if len(parkLots) != 1 || parkLots[0].lotNo != 1 {
+		t.Errorf("Parking lot should be 1")
+	}
+	parkLots = parking.GetParkingLotsWithPlateNo("plate-1-30")
+	if len(parkLots) != 1 || parkLots[0].lotNo != 30 {
 		t.Errorf("Parking lot should be 30")
 	}
 }
@@ -564,7 +573,7 @@ func TestVenicleParkingAndLeaveParkingWithSuccess(t *testing.T) {
 	if parkingLot.status != models.Available {
 		t.Errorf("Parking lot status should be available")
 	}
-	if parkingLot.vehicle != nil {
+	if len(parkingLot.allocations) != 0 {
 		t.Errorf("Vehicle in parking lot should be empty")
 	}
 }
@@ -617,10 +626,15 @@ func TestVenicleParkingAndLeaveParkingWithFlowSuccess(t *testing.T) {
 	}
 }
 
+// testParkingHelper is park vehicle n times, plate no has suffix after first vehicle because plate no is unique
 func testParkingHelper(parking IParking, vehicle IVehicle, n int, t *testing.T) {
 	total := len(parking.ParkingLot()) - (len(parking.ParkingLot()) - len(parking.GetAllAvailableLotNos()))
 	for i := 1; i <= n; i++ {
-		parkLot, err := parking.Park(vehicle)
+		parkVehicle := vehicle
+		if i > 1 {
+			parkVehicle = NewCar(fmt.Sprintf("%s-%d", vehicle.PlateNumber(), i), vehicle.Color(), vehicle.UsageLot())
+		}
+		parkLot, err := parking.Park(parkVehicle)
 
 		if parkLot == nil {
 			t.Errorf("Venicle should be parking")
@@ -678,8 +692,1104 @@ func testLeaveHelper(parking IParking, lotNos []int, t *testing.T) {
 		if parkingLot.status != models.Available {
 			t.Errorf("Parking lot status should be available")
 		}
-		if parkingLot.vehicle != nil {
+		if len(parkingLot.allocations) != 0 {
 			t.Errorf("Vehicle in parking lot should be empty")
 		}
 	}
 }
+
+func TestReserveParkingLotWithInputError(t *testing.T) {
+	mockName := "unit-testing"
+	mockPlate := "plate-1"
+	parking := NewParking(mockName)
+
+	isReserved, err := parking.Reserve(1, mockPlate)
+	if isReserved {
+		t.Errorf("Parking lot should not be reserved")
+	}
+	if !(err != nil && err.Error() == errmsgs.ParkingLotNotAvailableError().Error()) {
+		t.Errorf("Error should have and should be parking lot is not available")
+	}
+
+	isCreated, err := parking.CreateParkingLot(10)
+	if !isCreated {
+		t.Errorf("Create Should be create success")
+	}
+	if err != nil {
+		t.Errorf("Error should be empty")
+	}
+
+	isReserved, err = parking.Reserve(1, "")
+	if isReserved {
+		t.Errorf("Parking lot should not be reserved")
+	}
+	if !(err != nil && err.Error() == errmsgs.InternalServerError().Error()) {
+		t.Errorf("Error should have and should be internal server error")
+	}
+
+	isUnreserved, err := parking.Unreserve(1)
+	if isUnreserved {
+		t.Errorf("Parking lot should not be unreserved")
+	}
+	if !(err != nil && err.Error() == errmsgs.ParkingLotNotReservedError().Error()) {
+		t.Errorf("Error should have and should be parking lot is not reserved")
+	}
+
+	if len(parking.GetAllAvailableLotNos()) != 10 {
+		t.Errorf("All available lot should be 10")
+	}
+}
+
+func TestReserveParkingLotWithBusyLotAndDuplicatePlate(t *testing.T) {
+	mockName := "unit-testing"
+	var usage float32 = 1.0
+	parking := NewParking(mockName)
+	isCreated, err := parking.CreateParkingLot(10)
+	if !isCreated {
+		t.Errorf("Create Should be create success")
+	}
+	if err != nil {
+		t.Errorf("Error should be empty")
+	}
+
+	testParkingHelper(parking, NewCar("plate-1", "red", usage), 1, t)
+
+	isReserved, err := parking.Reserve(1, "plate-2")
+	if isReserved {
+		t.Errorf("Busy parking lot should not be reserved")
+	}
+	if !(err != nil && err.Error() == errmsgs.ParkingLotNotAvailableError().Error()) {
+		t.Errorf("Error should have and should be parking lot is not available")
+	}
+
+	isReserved, err = parking.Reserve(5, "plate-2")
+	if !isReserved || err != nil {
+		t.Errorf("Parking lot should be reserved")
+	}
+
+	isReserved, err = parking.Reserve(6, "plate-2")
+	if isReserved {
+		t.Errorf("Vehicle should not reserve two parking lots")
+	}
+	if !(err != nil && err.Error() == errmsgs.VehicalAlreadyParkingError().Error()) {
+		t.Errorf("Error should have and should be vehical already parking")
+	}
+
+	isReserved, err = parking.Reserve(6, "plate-1")
+	if isReserved {
+		t.Errorf("Parking vehicle should not reserve parking lot")
+	}
+	if !(err != nil && err.Error() == errmsgs.VehicalAlreadyParkingError().Error()) {
+		t.Errorf("Error should have and should be vehical already parking")
+	}
+
+	isReserved, err = parking.Reserve(5, "plate-3")
+	if isReserved {
+		t.Errorf("Reserved parking lot should not be reserved again")
+	}
+	if !(err != nil && err.Error() == errmsgs.ParkingLotNotAvailableError().Error()) {
+		t.Errorf("Error should have and should be parking lot is not available")
+	}
+}
+
+func TestReserveParkingLotWithParkSuccess(t *testing.T) {
+	mockName := "unit-testing"
+	var usage float32 = 1.0
+	parking := NewParking(mockName)
+	isCreated, err := parking.CreateParkingLot(3)
+	if !isCreated {
+		t.Errorf("Create Should be create success")
+	}
+	if err != nil {
+		t.Errorf("Error should be empty")
+	}
+
+	isReserved, err := parking.Reserve(1, "plate-reserve")
+	if !isReserved || err != nil {
+		t.Errorf("Parking lot should be reserved")
+	}
+	if len(parking.GetAllAvailableLotNos()) != 2 {
+		t.Errorf("All available lot should be 2")
+	}
+	if parking.ParkingLot()[1].status != models.Reserve {
+		t.Errorf("Parking lot status should be reserve")
+	}
+
+	// Other vehicle should skip reserved lot
+	parkLot, err := parking.Park(NewCar("plate-1", "red", usage))
+	if err != nil || parkLot == nil {
+		t.Errorf("Venicle should be parking")
+	}
+	if parkLot != nil && parkLot.lotNo != 2 {
+		t.Errorf("Parking lot no should be 2")
+	}
+	parkLot, err = parking.Park(NewCar("plate-2", "red", usage))
+	if err != nil || parkLot == nil {
+		t.Errorf("Venicle should be parking")
+	}
+	if parkLot != nil && parkLot.lotNo != 3 {
+		t.Errorf("Parking lot no should be 3")
+	}
+	parkLot, err = parking.Park(NewCar("plate-3", "red", usage))
+	if parkLot != nil {
+		t.Errorf("Venicle should not be parking")
+	}
+	if !(err != nil && err.Error() == errmsgs.ParkingLotIsFullError().Error()) {
+		t.Errorf("Error should have and should be parking lot is full")
+	}
+
+	// Reserved vehicle should claim reserved lot
+	parkLot, err = parking.Park(NewCar("plate-reserve", "blue", usage))
+	if err != nil || parkLot == nil {
+		t.Errorf("Venicle should be parking")
+	}
+	if parkLot != nil && parkLot.lotNo != 1 {
+		t.Errorf("Parking lot no should be 1")
+	}
+	parkingLot := parking.ParkingLot()[1]
+	if parkingLot.status != models.Busy {
+		t.Errorf("Parking lot status should be busy")
+	}
+	if parkingLot.reservedPlateNo != "" {
+		t.Errorf("Reserved plate no should be empty")
+	}
+
+	testLeaveHelper(parking, []int{1}, t)
+	isReserved, err = parking.Reserve(1, "plate-reserve")
+	if !isReserved || err != nil {
+		t.Errorf("Parking lot should be reserved again")
+	}
+}
+
+func TestUnreserveParkingLotWithSuccess(t *testing.T) {
+	mockName := "unit-testing"
+	var usage float32 = 1.0
+	parking := NewParking(mockName)
+	isCreated, err := parking.CreateParkingLot(3)
+	if !isCreated {
+		t.Errorf("Create Should be create success")
+	}
+	if err != nil {
+		t.Errorf("Error should be empty")
+	}
+
+	isReserved, err := parking.Reserve(1, "plate-reserve")
+	if !isReserved || err != nil {
+		t.Errorf("Parking lot should be reserved")
+	}
+
+	isUnreserved, err := parking.Unreserve(1)
+	if !isUnreserved || err != nil {
+		t.Errorf("Parking lot should be unreserved")
+	}
+	if len(parking.GetAllAvailableLotNos()) != 3 {
+		t.Errorf("All available lot should be 3")
+	}
+	if parking.ParkingLot()[1].status != models.Available {
+		t.Errorf("Parking lot status should be available")
+	}
+
+	testParkingHelper(parking, NewCar("plate-1", "red", usage), 1, t)
+}
+
+func TestVenicleParkingWithBiggerThanLotSize(t *testing.T) {
+	mockName := "unit-testing"
+	parking := NewParking(mockName)
+	isCreated, err := parking.CreateParkingLot(5)
+	if !isCreated {
+		t.Errorf("Create Should be create success")
+	}
+	if err != nil {
+		t.Errorf("Error should be empty")
+	}
+
+	testParkingHelper(parking, NewCar("plate-1", "red", 1), 1, t)
+	testParkingHelper(parking, NewCar("plate-2", "red", 1), 1, t)
+	testLeaveHelper(parking, []int{1}, t)
+
+	// Lot 1 is free but not adjacent with other free lots
+	truck := NewCar("truck-1", "blue", 2)
+	parkLot, err := parking.Park(truck)
+	if err != nil || parkLot == nil {
+		t.Fatalf("Truck should be parking")
+	}
+	if parkLot.lotNo != 3 {
+		t.Errorf("Parking lot no should be 3")
+	}
+	alloc := parkLot.allocationOf(truck)
+	if alloc == nil || len(alloc.LotNos()) != 2 || alloc.LotNos()[0] != 3 || alloc.LotNos()[1] != 4 {
+		t.Errorf("Truck should use lot 3 and 4")
+	}
+	if len(parking.GetAllAvailableLotNos()) != 2 {
+		t.Errorf("All available lot should be 2")
+	}
+	if parking.ParkingLot()[4].status != models.Busy {
+		t.Errorf("Parking lot status should be busy")
+	}
+	if len(parking.GetParkingLotsWithPlateNo("truck-1")) != 2 {
+		t.Errorf("Truck should be found in 2 parking lots")
+	}
+
+	parkLot, err = parking.Park(NewCar("truck-2", "blue", 2))
+	if parkLot != nil {
+		t.Errorf("Truck should not be parking")
+	}
+	if !(err != nil && err.Error() == errmsgs.ParkingLotIsFullError().Error()) {
+		t.Errorf("Error should have and should be parking lot is full")
+	}
+
+	// Leave at any lot of truck should free all lots
+	isLeave, err := parking.Leave(4)
+	if !isLeave || err != nil {
+		t.Errorf("Truck should be leave the parking")
+	}
+	if len(parking.GetAllAvailableLotNos()) != 4 {
+		t.Errorf("All available lot should be 4")
+	}
+	for _, lotNo := range []int{3, 4} {
+		parkingLot := parking.ParkingLot()[lotNo]
+		if parkingLot.status != models.Available || len(parkingLot.allocations) != 0 {
+			t.Errorf("Parking lot %d should be available", lotNo)
+		}
+	}
+}
+
+func TestVenicleParkingWithSmallerThanLotSize(t *testing.T) {
+	mockName := "unit-testing"
+	parking := NewParking(mockName)
+	isCreated, err := parking.CreateParkingLot(3)
+	if !isCreated {
+		t.Errorf("Create Should be create success")
+	}
+	if err != nil {
+		t.Errorf("Error should be empty")
+	}
+
+	testParkingHelper(parking, NewCar("motorcycle-1", "red", 0.5), 1, t)
+
+	// Car should not share lot with motorcycle
+	parkLot, err := parking.Park(NewCar("car-1", "red", 1))
+	if err != nil || parkLot == nil || parkLot.lotNo != 2 {
+		t.Errorf("Car should be parking at lot 2")
+	}
+
+	parkLot, err = parking.Park(NewCar("motorcycle-2", "blue", 0.5))
+	if err != nil || parkLot == nil || parkLot.lotNo != 1 {
+		t.Errorf("Motorcycle should share parking lot 1")
+	}
+	if len(parking.ParkingLot()[1].Vehicles()) != 2 {
+		t.Errorf("Parking lot 1 should have 2 vehicles")
+	}
+
+	parkLot, err = parking.Park(NewCar("motorcycle-3", "blue", 0.5))
+	if err != nil || parkLot == nil || parkLot.lotNo != 3 {
+		t.Errorf("Motorcycle should be parking at lot 3")
+	}
+
+	isLeave, err := parking.Leave(1)
+	if isLeave {
+		t.Errorf("Shared lot should require registration number")
+	}
+	if !(err != nil && err.Error() == errmsgs.VehicalPlateNoRequiredError().Error()) {
+		t.Errorf("Error should have and should be plate no required")
+	}
+
+	alloc, err := parking.LeaveVehicle(1, "motorcycle-3")
+	if alloc != nil {
+		t.Errorf("Motorcycle should not be leave from other lot")
+	}
+	if !(err != nil && err.Error() == errmsgs.VehicalNotParkingHereError().Error()) {
+		t.Errorf("Error should have and should be vehical not parking here")
+	}
+
+	alloc, err = parking.LeaveVehicle(1, "motorcycle-1")
+	if alloc == nil || err != nil {
+		t.Errorf("Motorcycle should be leave the parking")
+	}
+	parkingLot := parking.ParkingLot()[1]
+	if parkingLot.status != models.Busy || len(parkingLot.Vehicles()) != 1 {
+		t.Errorf("Parking lot 1 should still busy")
+	}
+	if len(parking.GetAllAvailableLotNos()) != 0 {
+		t.Errorf("All available lot should be 0")
+	}
+
+	testLeaveHelper(parking, []int{1}, t)
+}
+
+func TestNewVehicleWithVehicleTypes(t *testing.T) {
+	vehicle, err := NewVehicle(models.VehicleType("plane"), "plate-1", "red", VehicleAttributes{})
+	if vehicle != nil {
+		t.Errorf("Vehicle should be empty")
+	}
+	if !(err != nil && err.Error() == errmsgs.VehicleTypeInvalidError().Error()) {
+		t.Errorf("Error should have and should be vehicle type is invalid")
+	}
+
+	vehicle, err = NewVehicle(models.Accessible, "plate-1", "red", VehicleAttributes{})
+	if vehicle != nil {
+		t.Errorf("Vehicle should be empty")
+	}
+	if !(err != nil && err.Error() == errmsgs.VehiclePermitRequiredError().Error()) {
+		t.Errorf("Error should have and should be permit required")
+	}
+
+	for vehicleType, usage := range vehicleTypeUsageLots {
+		vehicle, err := NewVehicle(vehicleType, "plate-1", "red", VehicleAttributes{PermitNo: "permit-1"})
+		if err != nil || vehicle == nil {
+			t.Fatalf("Vehicle %s should be created", vehicleType)
+		}
+		if vehicle.Type() != vehicleType {
+			t.Errorf("Vehicle type should be %s", vehicleType)
+		}
+		if vehicle.UsageLot() != usage {
+			t.Errorf("Vehicle %s usage lot should be %v", vehicleType, usage)
+		}
+	}
+
+	vehicle, err = NewVehicle(models.Truck, "plate-1", "red", VehicleAttributes{UsageLot: 4})
+	if err != nil || vehicle.UsageLot() != 4 {
+		t.Errorf("Vehicle usage lot should be 4")
+	}
+
+	vehicle, _ = NewVehicle(models.ElectricVehicle, "plate-1", "red", VehicleAttributes{})
+	if ev, ok := vehicle.(IChargeableVehicle); !ok || !ev.NeedCharging() {
+		t.Errorf("Electric vehicle should need charging")
+	}
+
+	vehicle, _ = NewVehicle(models.Accessible, "plate-1", "red", VehicleAttributes{PermitNo: "permit-1"})
+	if permit, ok := vehicle.(IPermitVehicle); !ok || permit.PermitNo() != "permit-1" {
+		t.Errorf("Accessible vehicle permit no should be permit-1")
+	}
+}
+
+func TestParseVehicleWithInvalidSize(t *testing.T) {
+	for _, size := range []string{"0", "-1", "NaN", "Inf", "-Inf", "1e39"} {
+		if vehicle, err := parseVehicle("plate-1", "red", size); vehicle != nil || err == nil {
+			t.Errorf("Vehicle with size %s should be rejected", size)
+		}
+		if vehicle, err := parseVehicle("plate-1", "red", "--size="+size); vehicle != nil || err == nil {
+			t.Errorf("Vehicle with size option %s should be rejected", size)
+		}
+	}
+
+	vehicle, err := parseVehicle("plate-1", "red", "--size=2.5")
+	if err != nil || vehicle.UsageLot() != 2.5 {
+		t.Errorf("Vehicle usage lot should be 2.5")
+	}
+}
+
+func TestGetParkingLotsWithVehicleTypeWithSuccessData(t *testing.T) {
+	mockName := "unit-testing"
+	parking := NewParking(mockName)
+	isCreated, err := parking.CreateParkingLot(10)
+	if !isCreated {
+		t.Errorf("Create Should be create success")
+	}
+	if err != nil {
+		t.Errorf("Error should be empty")
+	}
+
+	truck, _ := NewVehicle(models.Truck, "truck-1", "red", VehicleAttributes{})
+	motorcycle, _ := NewVehicle(models.Motorcycle, "motorcycle-1", "red", VehicleAttributes{})
+	for _, vehicle := range []IVehicle{NewCar("car-1", "red", 1), truck, motorcycle} {
+		if _, err := parking.Park(vehicle); err != nil {
+			t.Errorf("Error should be empty")
+		}
+	}
+
+	parkLots := parking.GetParkingLotsWithVehicleType(models.Truck)
+	if len(parkLots) != 2 || parkLots[0].lotNo != 2 || parkLots[1].lotNo != 3 {
+		t.Errorf("Truck should be found at lot 2 and 3")
+	}
+	parkLots = parking.GetParkingLotsWithVehicleType(models.Motorcycle)
+	if len(parkLots) != 1 || parkLots[0].lotNo != 4 {
+		t.Errorf("Motorcycle should be found at lot 4")
+	}
+	parkLots = parking.GetParkingLotsWithVehicleType(models.Bus)
+	if parkLots != nil || len(parkLots) > 0 {
+		t.Errorf("Parking lot should be empty")
+	}
+}
+
+func TestCreateParkingLotWithCategoryInputError(t *testing.T) {
+	mockName := "unit-testing"
+	parking := NewParking(mockName)
+
+	isCreated, err := parking.CreateParkingLot(10, LotCategoryRange{From: 1, To: 2, Category: models.LotCategory("roof")})
+	if isCreated {
+		t.Errorf("Should be cannot create parking lot")
+	}
+	if !(err != nil && err.Error() == errmsgs.LotCategoryInvalidError().Error()) {
+		t.Errorf("Error should have and should be lot category is invalid")
+	}
+
+	isCreated, err = parking.CreateParkingLot(10, LotCategoryRange{From: 5, To: 11, Category: models.LargeLot})
+	if isCreated {
+		t.Errorf("Should be cannot create parking lot")
+	}
+	if !(err != nil && err.Error() == errmsgs.LotCategoryRangeInvalidError().Error()) {
+		t.Errorf("Error should have and should be lot category range is invalid")
+	}
+
+	if len(parking.ParkingLot()) != 0 {
+		t.Errorf("Parking lot should be empty")
+	}
+}
+
+func TestVenicleParkingWithLotCategories(t *testing.T) {
+	mockName := "unit-testing"
+	parking := NewParking(mockName)
+	isCreated, err := parking.CreateParkingLot(6,
+		LotCategoryRange{From: 1, To: 1, Category: models.DisabledLot},
+		LotCategoryRange{From: 2, To: 2, Category: models.ChargingLot},
+		LotCategoryRange{From: 5, To: 6, Category: models.LargeLot},
+	)
+	if !isCreated {
+		t.Errorf("Create Should be create success")
+	}
+	if err != nil {
+		t.Errorf("Error should be empty")
+	}
+	if parking.ParkingLot()[3].Category() != models.CompactLot {
+		t.Errorf("Parking lot category should be compact")
+	}
+	if parking.ParkingLot()[5].lotSize != lotCategorySizes[models.LargeLot] {
+		t.Errorf("Large parking lot size should be %v", lotCategorySizes[models.LargeLot])
+	}
+
+	testCases := []struct {
+		vehicleType models.VehicleType
+		lotNo       int
+	}{
+		{vehicleType: models.Car, lotNo: 3},
+		{vehicleType: models.Truck, lotNo: 5},
+		{vehicleType: models.ElectricVehicle, lotNo: 2},
+		{vehicleType: models.Accessible, lotNo: 1},
+		{vehicleType: models.ElectricVehicle, lotNo: 4},
+		{vehicleType: models.Car, lotNo: 6},
+	}
+	for ind, testCase := range testCases {
+		vehicle, _ := NewVehicle(testCase.vehicleType, fmt.Sprintf("plate-%d", ind), "red", VehicleAttributes{PermitNo: "permit"})
+		parkLot, err := parking.Park(vehicle)
+		if err != nil || parkLot == nil {
+			t.Fatalf("Vehicle %s should be parking", testCase.vehicleType)
+		}
+		if parkLot.lotNo != testCase.lotNo {
+			t.Errorf("Vehicle %s should park at lot %d but %d", testCase.vehicleType, testCase.lotNo, parkLot.lotNo)
+		}
+	}
+}
+
+func TestVenicleParkingWithLotPreference(t *testing.T) {
+	mockName := "unit-testing"
+	parking := NewParking(mockName)
+	isCreated, err := parking.CreateParkingLot(3, LotCategoryRange{From: 1, To: 1, Category: models.ChargingLot})
+	if !isCreated {
+		t.Errorf("Create Should be create success")
+	}
+	if err != nil {
+		t.Errorf("Error should be empty")
+	}
+
+	isSet, err := parking.SetLotPreference(models.VehicleType("plane"), []models.LotCategory{models.CompactLot})
+	if isSet || !(err != nil && err.Error() == errmsgs.VehicleTypeInvalidError().Error()) {
+		t.Errorf("Error should have and should be vehicle type is invalid")
+	}
+	isSet, err = parking.SetLotPreference(models.Car, []models.LotCategory{})
+	if isSet || !(err != nil && err.Error() == errmsgs.LotCategoryInvalidError().Error()) {
+		t.Errorf("Error should have and should be lot category is invalid")
+	}
+
+	// Car can not use charging lot by default
+	for _, lotNo := range []int{2, 3} {
+		parkLot, err := parking.Park(NewCar(fmt.Sprintf("plate-%d", lotNo), "red", 1))
+		if err != nil || parkLot == nil || parkLot.lotNo != lotNo {
+			t.Errorf("Car should park at lot %d", lotNo)
+		}
+	}
+	parkLot, err := parking.Park(NewCar("plate-4", "red", 1))
+	if parkLot != nil {
+		t.Errorf("Car should not be parking")
+	}
+	if !(err != nil && err.Error() == errmsgs.ParkingLotIsFullError().Error()) {
+		t.Errorf("Error should have and should be parking lot is full")
+	}
+
+	isSet, err = parking.SetLotPreference(models.Car, []models.LotCategory{models.CompactLot, models.ChargingLot})
+	if !isSet || err != nil {
+		t.Errorf("Lot preference should be set")
+	}
+	parkLot, err = parking.Park(NewCar("plate-4", "red", 1))
+	if err != nil || parkLot == nil || parkLot.lotNo != 1 {
+		t.Errorf("Car should park at lot 1")
+	}
+}
+
+func TestVehicleNeedChargingParkingWithSuccess(t *testing.T) {
+	mockName := "unit-testing"
+	parking := NewParking(mockName)
+	parking.CreateParkingLot(3, LotCategoryRange{From: 3, To: 3, Category: models.ChargingLot})
+
+	// Charging lot is tried first even when lot preference of EV does not have it
+	isSet, err := parking.SetLotPreference(models.ElectricVehicle, []models.LotCategory{models.CompactLot})
+	if !isSet || err != nil {
+		t.Errorf("Lot preference should be set")
+	}
+	for ind, lotNo := range []int{3, 1} {
+		vehicle, _ := NewVehicle(models.ElectricVehicle, fmt.Sprintf("ev-%d", ind), "red", VehicleAttributes{})
+		parkLot, err := parking.Park(vehicle)
+		if err != nil || parkLot == nil || parkLot.lotNo != lotNo {
+			t.Errorf("EV that need charging should park at lot %d", lotNo)
+		}
+	}
+
+	parkLot, err := parking.Park(NewCar("plate-1", "red", 1))
+	if err != nil || parkLot == nil || parkLot.lotNo != 2 {
+		t.Errorf("Car should park at lot 2")
+	}
+}
+
+func TestCreateLevelWithSuccess(t *testing.T) {
+	mockName := "unit-testing"
+	parking := NewParking(mockName)
+
+	isCreated, err := parking.CreateLevel(0)
+	if isCreated || !(err != nil && err.Error() == errmsgs.InternalServerError().Error()) {
+		t.Errorf("Error should have and should be negative value")
+	}
+
+	for _, lotAmount := range []int{5, 20} {
+		isCreated, err := parking.CreateLevel(lotAmount, LotCategoryRange{From: 1, To: 1, Category: models.LargeLot})
+		if !isCreated {
+			t.Errorf("Create Should be create success")
+		}
+		if err != nil {
+			t.Errorf("Error should be empty")
+		}
+	}
+
+	if len(parking.Levels()) != 2 {
+		t.Errorf("Levels should be 2")
+	}
+	if len(parking.ParkingLot()) != 25 {
+		t.Errorf("All parking lot should be 25")
+	}
+
+	parkingLot := parking.ParkingLot()[6]
+	if parkingLot.Label() != "L2-001" {
+		t.Errorf("Parking lot label should be L2-001 but %s", parkingLot.Label())
+	}
+	if parkingLot.Category() != models.LargeLot {
+		t.Errorf("Parking lot category should be large")
+	}
+	if parking.ParkingLot()[25].Label() != "L2-020" {
+		t.Errorf("Parking lot label should be L2-020")
+	}
+
+	testCases := []struct {
+		lotLabel string
+		lotNo    int
+		isValid  bool
+	}{
+		{lotLabel: "L1-001", lotNo: 1, isValid: true},
+		{lotLabel: "L2-017", lotNo: 22, isValid: true},
+		{lotLabel: "22", lotNo: 22, isValid: true},
+		{lotLabel: "L1-006", isValid: false},
+		{lotLabel: "L3-001", isValid: false},
+		{lotLabel: "slot", isValid: false},
+	}
+	for _, testCase := range testCases {
+		lotNo, err := parking.ParseLotNo(testCase.lotLabel)
+		if testCase.isValid && (err != nil || lotNo != testCase.lotNo) {
+			t.Errorf("Lot label %s should be lot no %d", testCase.lotLabel, testCase.lotNo)
+		}
+		if !testCase.isValid && !(err != nil && err.Error() == errmsgs.LotNoInvalidError().Error()) {
+			t.Errorf("Lot label %s should be invalid", testCase.lotLabel)
+		}
+	}
+}
+
+func TestVenicleParkingWithLevelPriority(t *testing.T) {
+	mockName := "unit-testing"
+	parking := NewParking(mockName)
+	for _, lotAmount := range []int{2, 2, 2} {
+		if _, err := parking.CreateLevel(lotAmount); err != nil {
+			t.Errorf("Error should be empty")
+		}
+	}
+
+	isSet, err := parking.SetLevelPriority([]int{4})
+	if isSet || !(err != nil && err.Error() == errmsgs.LevelInvalidError().Error()) {
+		t.Errorf("Error should have and should be level is invalid")
+	}
+	isSet, err = parking.SetLevelPriority([]int{2, 2})
+	if isSet || !(err != nil && err.Error() == errmsgs.LevelInvalidError().Error()) {
+		t.Errorf("Error should have and should be level is invalid")
+	}
+
+	// Lowest level first by default
+	parkLot, err := parking.Park(NewCar("plate-1", "red", 1))
+	if err != nil || parkLot == nil || parkLot.Label() != "L1-001" {
+		t.Errorf("Car should park at L1-001")
+	}
+
+	isSet, err = parking.SetLevelPriority([]int{3})
+	if !isSet || err != nil {
+		t.Errorf("Level priority should be set")
+	}
+	expectLabels := []string{"L3-001", "L3-002", "L1-002", "L2-001"}
+	for ind, expectLabel := range expectLabels {
+		parkLot, err := parking.Park(NewCar(fmt.Sprintf("plate-%d", ind+2), "red", 1))
+		if err != nil || parkLot == nil || parkLot.Label() != expectLabel {
+			t.Errorf("Car should park at %s", expectLabel)
+		}
+	}
+
+	// Truck can not use lots across levels
+	testLeaveHelper(parking, []int{2, 3}, t)
+	parkLot, err = parking.Park(NewCar("truck-1", "red", 2))
+	if err != nil || parkLot == nil || parkLot.Label() != "L2-001" {
+		t.Errorf("Truck should park at L2-001")
+	}
+	parkLot, err = parking.Park(NewCar("truck-2", "red", 2))
+	if parkLot != nil {
+		t.Errorf("Truck should not be parking")
+	}
+	if !(err != nil && err.Error() == errmsgs.ParkingLotIsFullError().Error()) {
+		t.Errorf("Error should have and should be parking lot is full")
+	}
+}
+
+// mockClock is clock that time move only when add
+type mockClock struct {
+	now time.Time
+}
+
+func newMockClock() *mockClock {
+	return &mockClock{now: time.Date(2020, 9, 28, 8, 0, 0, 0, time.UTC)}
+}
+
+func (clock *mockClock) Now() time.Time {
+	return clock.now
+}
+
+func (clock *mockClock) add(duration time.Duration) {
+	clock.now = clock.now.Add(duration)
+}
+
+func TestVenicleParkingAndLeaveParkingWithDuration(t *testing.T) {
+	mockName := "unit-testing"
+	clock := newMockClock()
+	parking := NewParkingWithClock(mockName, clock)
+	isCreated, err := parking.CreateParkingLot(3)
+	if !isCreated {
+		t.Errorf("Create Should be create success")
+	}
+	if err != nil {
+		t.Errorf("Error should be empty")
+	}
+
+	entryTime := clock.Now()
+	parkLot, err := parking.Park(NewCar("plate-1", "red", 1))
+	if err != nil || parkLot == nil {
+		t.Fatalf("Venicle should be parking")
+	}
+	alloc := parkLot.allocations[0]
+	if !alloc.EntryTime().Equal(entryTime) {
+		t.Errorf("Entry time should be %s", entryTime)
+	}
+	if alloc.Duration() != 0 || !alloc.ExitTime().IsZero() {
+		t.Errorf("Parking vehicle should have no duration")
+	}
+
+	clock.add(90 * time.Minute)
+	alloc, err = parking.LeaveVehicle(1, "")
+	if err != nil || alloc == nil {
+		t.Fatalf("Venicle should be leave the parking")
+	}
+	if !alloc.ExitTime().Equal(entryTime.Add(90 * time.Minute)) {
+		t.Errorf("Exit time should be %s", entryTime.Add(90*time.Minute))
+	}
+	if alloc.Duration() != 90*time.Minute {
+		t.Errorf("Duration should be 90 minutes but %s", alloc.Duration())
+	}
+}
+
+func TestVenicleLeaveParkingWithTariff(t *testing.T) {
+	mockName := "unit-testing"
+	clock := newMockClock()
+	parking := NewParkingWithClock(mockName, clock)
+	isCreated, err := parking.CreateParkingLot(3, LotCategoryRange{From: 3, To: 3, Category: models.ChargingLot})
+	if !isCreated {
+		t.Errorf("Create Should be create success")
+	}
+	if err != nil {
+		t.Errorf("Error should be empty")
+	}
+	testParkingHelper(parking, NewCar("plate-1", "red", 1), 1, t)
+
+	// Parking without tariff is free
+	clock.add(time.Hour)
+	alloc, err := parking.LeaveVehicle(1, "")
+	if err != nil || alloc == nil || alloc.Fee() != 0 {
+		t.Errorf("Fee should be 0")
+	}
+
+	parking.SetTariff(newTestTariff(t))
+	if parking.Tariff() == nil {
+		t.Errorf("Tariff should be set")
+	}
+	ev, _ := NewVehicle(models.ElectricVehicle, "ev-1", "red", VehicleAttributes{})
+	for _, vehicle := range []IVehicle{NewCar("plate-1", "red", 1), ev} {
+		if _, err := parking.Park(vehicle); err != nil {
+			t.Errorf("Error should be empty")
+		}
+	}
+
+	clock.add(2*time.Hour + 15*time.Minute)
+	alloc, err = parking.LeaveVehicle(1, "")
+	if err != nil || alloc == nil || alloc.Fee() != 40 {
+		t.Errorf("Fee of car should be 40")
+	}
+	alloc, err = parking.LeaveVehicle(3, "")
+	if err != nil || alloc == nil || alloc.Fee() != 60 {
+		t.Errorf("Fee of EV at charging lot should be 60")
+	}
+}
+
+func TestVenicleLeaveParkingWithTicket(t *testing.T) {
+	mockName := "unit-testing"
+	parking := NewParking(mockName)
+	isCreated, err := parking.CreateParkingLot(3)
+	if !isCreated {
+		t.Errorf("Create Should be create success")
+	}
+	if err != nil {
+		t.Errorf("Error should be empty")
+	}
+
+	ticketIDs := map[string]bool{}
+	for _, plateNo := range []string{"plate-1", "plate-2"} {
+		vehicle := NewCar(plateNo, "red", 1)
+		parkLot, err := parking.Park(vehicle)
+		if err != nil || parkLot == nil {
+			t.Fatalf("Venicle should be parking")
+		}
+		ticketID := parkLot.allocationOf(vehicle).TicketID()
+		if ticketID == "" || ticketIDs[ticketID] {
+			t.Errorf("Ticket id should be unique")
+		}
+		ticketIDs[ticketID] = true
+	}
+
+	alloc, err := parking.LeaveByTicket("T999999")
+	if alloc != nil || !(err != nil && err.Error() == errmsgs.TicketInvalidError().Error()) {
+		t.Errorf("Error should have and should be ticket is invalid")
+	}
+
+	alloc, err = parking.LeaveByTicket("T000002")
+	if err != nil || alloc == nil {
+		t.Fatalf("Venicle should be leave the parking")
+	}
+	if alloc.Vehicle().PlateNumber() != "plate-2" || alloc.LotNos()[0] != 2 {
+		t.Errorf("Ticket T000002 should be plate-2 at lot 2")
+	}
+	if parking.ParkingLot()[2].status != models.Available {
+		t.Errorf("Parking lot status should be available")
+	}
+
+	alloc, err = parking.LeaveByTicket("T000002")
+	if alloc != nil || !(err != nil && err.Error() == errmsgs.TicketAlreadyUsedError().Error()) {
+		t.Errorf("Error should have and should be ticket was already used")
+	}
+
+	// New ticket id should not reuse leaved ticket id
+	vehicle := NewCar("plate-3", "red", 1)
+	parkLot, _ := parking.Park(vehicle)
+	if ticketIDs[parkLot.allocationOf(vehicle).TicketID()] {
+		t.Errorf("Ticket id should be unique")
+	}
+}
+
+func TestVenicleLeaveParkingWithPlateNo(t *testing.T) {
+	mockName := "unit-testing"
+	parking := NewParking(mockName)
+	isCreated, err := parking.CreateParkingLot(3)
+	if !isCreated {
+		t.Errorf("Create Should be create success")
+	}
+	if err != nil {
+		t.Errorf("Error should be empty")
+	}
+
+	alloc, err := parking.LeaveByPlateNo("plate-1")
+	if alloc != nil || !(err != nil && err.Error() == errmsgs.VehicalNotParkingHereError().Error()) {
+		t.Errorf("Error should have and should be vehical not parking here")
+	}
+
+	parking.Park(NewCar("plate-1", "red", 1))
+	parking.Park(NewCar("truck-1", "red", 2))
+
+	alloc, err = parking.LeaveByPlateNo("truck-1")
+	if err != nil || alloc == nil {
+		t.Fatalf("Venicle should be leave the parking")
+	}
+	if len(alloc.LotNos()) != 2 || len(parking.GetAllAvailableLotNos()) != 2 {
+		t.Errorf("Truck should free 2 parking lots")
+	}
+}
+
+func TestParkVehicleWithPlateNoAlreadyParkingError(t *testing.T) {
+	parking := NewParking("unit-testing")
+	parking.CreateParkingLot(3)
+
+	parkLot, err := parking.Park(NewCar("plate-1", "red", 1))
+	if parkLot == nil || err != nil {
+		t.Errorf("Vehicle should be parking")
+	}
+
+	parkLot, err = parking.Park(NewCar("plate-1", "blue", 1))
+	if parkLot != nil {
+		t.Errorf("Vehicle with same plate no should not be parking")
+	}
+	if !(err != nil && err.Error() == errmsgs.VehicalAlreadyParkingError().Error()) {
+		t.Errorf("Error should have and should be vehical already parking")
+	}
+	if len(parking.GetAllAvailableLotNos()) != 2 {
+		t.Errorf("All available lot should be 2")
+	}
+	if len(parking.GetParkingLotsWithPlateNo("plate-1")) != 1 {
+		t.Errorf("Parking lot with plate no should be 1")
+	}
+
+	parking.Leave(1)
+	if len(parking.GetParkingLotsWithPlateNo("plate-1")) != 0 {
+		t.Errorf("Parking lot with plate no should be empty")
+	}
+
+	parkLot, err = parking.Park(NewCar("plate-1", "blue", 1))
+	if parkLot == nil || err != nil {
+		t.Errorf("Vehicle should be parking again after leave")
+	}
+}
+
+func TestGetParkingLotsWithCarColorAfterLeaveSuccessData(t *testing.T) {
+	parking := NewParking("unit-testing")
+	parking.CreateParkingLot(5)
+	parking.Park(NewCar("plate-1", "Red", 1))
+	parking.Park(NewCar("plate-2", "blue", 1))
+	parking.Park(NewCar("plate-3", "red", 1))
+	parking.Park(NewCar("moto-1", "red", 0.5))
+	parking.Park(NewCar("moto-2", "red", 0.5))
+
+	parkLots := parking.GetParkingLotsWithCarColor("RED")
+	if len(parkLots) != 3 {
+		t.Errorf("Parking lot should be 3")
+	}
+	for i, lotNo := range []int{1, 3, 4} {
+		if len(parkLots) > i && parkLots[i].lotNo != lotNo {
+			t.Errorf("Parking lot no should be %d", lotNo)
+		}
+	}
+
+	parking.LeaveByPlateNo("plate-1")
+	parking.LeaveByPlateNo("moto-1")
+	parkLots = parking.GetParkingLotsWithCarColor("red")
+	if len(parkLots) != 2 || parkLots[0].lotNo != 3 || parkLots[1].lotNo != 4 {
+		t.Errorf("Parking lot should be 3 and 4")
+	}
+
+	parking.LeaveByPlateNo("plate-2")
+	if len(parking.GetParkingLotsWithCarColor("blue")) != 0 {
+		t.Errorf("Parking lot should be empty")
+	}
+}
+
+// benchmarkParkingHelper is parking that half of 50k lots are busy and only few vehicles are white
+func benchmarkParkingHelper(b *testing.B) IParking {
+	lotAmount := 50000
+	parking := NewParking("benchmark")
+	parking.CreateParkingLot(lotAmount)
+	for i := 1; i <= lotAmount/2; i++ {
+		color := "red"
+		if i%1000 == 0 {
+			color = "white"
+		}
+		if _, err := parking.Park(NewCar(fmt.Sprintf("plate-%d", i), color, 1)); err != nil {
+			b.Fatalf("Vehicle should be parking")
+		}
+	}
+	return parking
+}
+
+func BenchmarkGetParkingLotsWithCarColor(b *testing.B) {
+	parking := benchmarkParkingHelper(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if len(parking.GetParkingLotsWithCarColor("White")) != 25 {
+			b.Fatalf("Parking lot should be 25")
+		}
+	}
+}
+
+func BenchmarkGetParkingLotsWithPlateNo(b *testing.B) {
+	parking := benchmarkParkingHelper(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if len(parking.GetParkingLotsWithPlateNo("plate-12345")) != 1 {
+			b.Fatalf("Parking lot should be 1")
+		}
+	}
+}
+
+func BenchmarkLeaveCommand(b *testing.B) {
+	parkingSites := NewParkingSites()
+	parkingSites.Create(defaultParkingSiteName)
+	parking, _ := parkingSites.Get(defaultParkingSiteName)
+	parking.CreateParkingLot(50000)
+	commands := &strings.Builder{}
+	for i := 0; i < b.N; i++ {
+		fmt.Fprintf(commands, "park plate-%d red\nleave 1\n", i)
+	}
+	b.ResetTimer()
+	newParkingLotCommandInput(strings.NewReader(commands.String()), ioutil.Discard, parkingSites, parking).start()
+}
+
+func TestParkingLotWithNoAndLotCountWithSuccess(t *testing.T) {
+	parking := NewParking("unit-testing")
+	parking.CreateParkingLot(3)
+	parking.Park(NewCar("plate-1", "red", 1))
+
+	if parking.LotCount() != 3 {
+		t.Errorf("Lot count should be 3")
+	}
+	parkingLot := parking.ParkingLotWithNo(1)
+	if parkingLot == nil || len(parkingLot.Vehicles()) != 1 || parkingLot.Vehicles()[0].PlateNumber() != "plate-1" {
+		t.Errorf("Lot 1 should have plate-1")
+	}
+	if parking.ParkingLotWithNo(4) != nil {
+		t.Errorf("Lot 4 should be nil")
+	}
+}
+
+func TestLeaveReturnCopyOfAllocation(t *testing.T) {
+	parking := NewParking("unit-testing").(*Parking)
+	parking.CreateParkingLot(2)
+	parking.Park(NewCar("plate-1", "red", 1))
+	parking.Park(NewCar("plate-2", "red", 1))
+
+	alloc, err := parking.LeaveByTicket("T000001")
+	if err != nil || alloc == parking.tickets["T000001"] {
+		t.Errorf("Leave by ticket should return copy of allocation")
+	}
+	alloc, err = parking.LeaveByPlateNo("plate-2")
+	if err != nil || alloc == parking.tickets["T000002"] {
+		t.Errorf("Leave by plate no should return copy of allocation")
+	}
+}
+
+func TestParkConcurrentlyWithoutDoubleAllocation(t *testing.T) {
+	parking := NewParking("unit-testing")
+	parking.CreateParkingLot(100)
+
+	var (
+		wg         sync.WaitGroup
+		mutex      sync.Mutex
+		parkedLots = map[int]string{}
+		fullErrors int
+	)
+	for worker := 0; worker < 50; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for i := 0; i < 5; i++ {
+				plateNo := fmt.Sprintf("plate-%d-%d", worker, i)
+				parkLot, err := parking.Park(NewCar(plateNo, "red", 1))
+
+				mutex.Lock()
+				if err != nil {
+					if err.Error() == errmsgs.ParkingLotIsFullError().Error() {
+						fullErrors++
+					}
+				} else if otherPlateNo, ok := parkedLots[parkLot.lotNo]; ok {
+					t.Errorf("Lot no %d should not be allocated to %s and %s", parkLot.lotNo, otherPlateNo, plateNo)
+				} else {
+					parkedLots[parkLot.lotNo] = plateNo
+				}
+				mutex.Unlock()
+			}
+		}(worker)
+	}
+	wg.Wait()
+
+	if len(parkedLots) != 100 {
+		t.Errorf("Parked lots should be 100")
+	}
+	if fullErrors != 150 {
+		t.Errorf("Parking lot is full error should be 150")
+	}
+	for lotNo, parkingLot := range parking.ParkingLot() {
+		if len(parkingLot.allocations) != 1 || parkingLot.allocations[0].vehicle.PlateNumber() != parkedLots[lotNo] {
+			t.Errorf("Lot no %d should have only %s", lotNo, parkedLots[lotNo])
+		}
+	}
+}
+
+func TestParkLeaveAndQueryConcurrently(t *testing.T) {
+	lotAmount := 30
+	parking := NewParking("unit-testing")
+	parking.CreateParkingLot(lotAmount)
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < 20; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			plateNo := fmt.Sprintf("plate-%d", worker)
+			usage := float32(worker%3) * 0.5
+			for i := 0; i < 100; i++ {
+				if _, err := parking.Park(NewCar(plateNo, "red", usage)); err != nil {
+					continue
+				}
+				if _, err := parking.LeaveByPlateNo(plateNo); err != nil {
+					t.Errorf("Vehicle %s should be leave", plateNo)
+				}
+			}
+		}(worker)
+	}
+
+	for reader := 0; reader < 5; reader++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				// Snapshot of lots should be consistent with available lots in the same snapshot
+				available := 0
+				for _, parkingLot := range parking.ParkingLot() {
+					if parkingLot.status == models.Available {
+						available++
+						if len(parkingLot.allocations) != 0 {
+							t.Errorf("Available lot should not have vehicle")
+						}
+					}
+				}
+				if available > lotAmount {
+					t.Errorf("Available lot should not be more than %d", lotAmount)
+				}
+				parking.GetParkingLotsWithCarColor("red")
+				parking.GetParkingLotsWithPlateNo("plate-1")
+				parking.GetAllAvailableLotNos()
+				parking.GetAvailableLot()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(parking.GetAllAvailableLotNos()) != lotAmount {
+		t.Errorf("All available lot should be %d", lotAmount)
+	}
+	if len(parking.GetParkingLotsWithCarColor("red")) != 0 {
+		t.Errorf("Parking lot with color should be empty")
+	}
+}
@@ -0,0 +1,290 @@
+package services
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"parkinglot/errmsgs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// reportInterval is length of each occupancy point of report
+	reportInterval = time.Hour
+	// reportPeakHours is amount of busiest hours of day in report
+	reportPeakHours = 3
+	// reportMaxRange is longest range of report, it limit amount of occupancy points
+	reportMaxRange = 366 * 24 * time.Hour
+)
+
+// Report is occupancy analytics of parking that computed from visits between from and to
+// Vehicle that still parking is counted until now of parking clock
+type Report struct {
+	From                time.Time        `json:"from"`
+	To                  time.Time        `json:"to"`
+	TotalSlots          int              `json:"total_slots"`
+	Visits              int              `json:"visits"`
+	AverageDwellMinutes float64          `json:"average_dwell_minutes"`
+	Occupancy           []OccupancyPoint `json:"occupancy"`
+	PeakHours           []PeakHour       `json:"peak_hours"`
+	Turnover            []SlotTurnover   `json:"turnover"`
+	Colours             []ReportCount    `json:"colours"`
+	VehicleTypes        []ReportCount    `json:"vehicle_types"`
+}
+
+// OccupancyPoint is percentage of slots that were used in interval that start at time
+type OccupancyPoint struct {
+	Time       time.Time `json:"time"`
+	Percentage float64   `json:"percentage"`
+}
+
+// PeakHour is hour of day with average occupancy percentage of that hour
+type PeakHour struct {
+	Hour       int     `json:"hour"`
+	Percentage float64 `json:"percentage"`
+}
+
+// SlotTurnover is amount of visits that used slot
+type SlotTurnover struct {
+	Slot   string `json:"slot"`
+	Visits int    `json:"visits"`
+}
+
+// ReportCount is amount of visits of colour or vehicle type
+type ReportCount struct {
+	Name   string `json:"name"`
+	Visits int    `json:"visits"`
+}
+
+// NewReport is report of parking from visits between from and to
+// To must be after from and range must not be longer than 366 days
+// Colours are counted without case and shown as first visit colour
+func NewReport(parking IParking, from, to time.Time) (Report, error) {
+	if !to.After(from) || to.Sub(from) > reportMaxRange {
+		return Report{}, errmsgs.ReportRangeInvalidError()
+	}
+
+	allocs := parking.GetHistoryBetween(from, to)
+	totalSlots := parking.LotCount()
+	report := Report{
+		From:       from,
+		To:         to,
+		TotalSlots: totalSlots,
+		Visits:     len(allocs),
+	}
+
+	var (
+		dwell     time.Duration
+		leftCount int
+	)
+	for _, alloc := range allocs {
+		if !alloc.ExitTime().IsZero() {
+			dwell += alloc.Duration()
+			leftCount++
+		}
+	}
+	if leftCount != 0 {
+		report.AverageDwellMinutes = roundReportValue((dwell / time.Duration(leftCount)).Minutes())
+	}
+
+	report.Occupancy = reportOccupancy(allocs, from, to, parking.Clock().Now(), totalSlots)
+	report.PeakHours = reportPeakHourList(report.Occupancy)
+	report.Turnover = reportTurnover(parking, allocs, totalSlots)
+	report.Colours = reportCounts(allocs, func(alloc *Allocation) string {
+		return alloc.Vehicle().Color()
+	})
+	report.VehicleTypes = reportCounts(allocs, func(alloc *Allocation) string {
+		return string(alloc.Vehicle().Type())
+	})
+	return report, nil
+}
+
+// reportOccupancy is local function for occupancy of each interval from from to to
+// Vehicle smaller than lot use part of lot, other vehicles use all of their lots
+// Vehicle that still parking use its lots until now
+func reportOccupancy(allocs []*Allocation, from, to, now time.Time, totalSlots int) []OccupancyPoint {
+	points := []OccupancyPoint{}
+	for start := from; start.Before(to); start = start.Add(reportInterval) {
+		end := start.Add(reportInterval)
+		if end.After(to) {
+			end = to
+		}
+
+		var usedSlotTime float64
+		for _, alloc := range allocs {
+			entryTime, exitTime := alloc.EntryTime(), alloc.ExitTime()
+			if exitTime.IsZero() {
+				exitTime = now
+			}
+			if exitTime.After(end) {
+				exitTime = end
+			}
+			if entryTime.Before(start) {
+				entryTime = start
+			}
+			if exitTime.After(entryTime) {
+				usedSlotTime += exitTime.Sub(entryTime).Seconds() * reportUsedSlots(alloc)
+			}
+		}
+
+		percentage := 0.0
+		if totalSlots != 0 {
+			percentage = math.Min(100, usedSlotTime*100/(end.Sub(start).Seconds()*float64(totalSlots)))
+		}
+		points = append(points, OccupancyPoint{Time: start, Percentage: roundReportValue(percentage)})
+	}
+	return points
+}
+
+// reportUsedSlots is local function for amount of slots that vehicle of allocation use
+func reportUsedSlots(alloc *Allocation) float64 {
+	if usage := float64(alloc.Vehicle().UsageLot()); usage < 1 {
+		return usage
+	}
+	return float64(len(alloc.LotNos()))
+}
+
+// reportPeakHourList is local function for hours of day with the highest average occupancy
+func reportPeakHourList(points []OccupancyPoint) []PeakHour {
+	totals := map[int]float64{}
+	counts := map[int]int{}
+	for _, point := range points {
+		totals[point.Time.Hour()] += point.Percentage
+		counts[point.Time.Hour()]++
+	}
+
+	peakHours := []PeakHour{}
+	for hour, total := range totals {
+		if total == 0 {
+			continue
+		}
+		peakHours = append(peakHours, PeakHour{Hour: hour, Percentage: roundReportValue(total / float64(counts[hour]))})
+	}
+	sort.Slice(peakHours, func(i, j int) bool {
+		if peakHours[i].Percentage != peakHours[j].Percentage {
+			return peakHours[i].Percentage > peakHours[j].Percentage
+		}
+		return peakHours[i].Hour < peakHours[j].Hour
+	})
+	if len(peakHours) > reportPeakHours {
+		peakHours = peakHours[:reportPeakHours]
+	}
+	return peakHours
+}
+
+// reportTurnover is local function for visits of each slot in lot no order
+func reportTurnover(parking IParking, allocs []*Allocation, totalSlots int) []SlotTurnover {
+	visits := map[int]int{}
+	for _, alloc := range allocs {
+		for _, lotNo := range alloc.LotNos() {
+			visits[lotNo]++
+		}
+	}
+
+	lotNos := make([]int, 0, totalSlots)
+	for lotNo := 1; lotNo <= totalSlots; lotNo++ {
+		lotNos = append(lotNos, lotNo)
+	}
+	turnover := make([]SlotTurnover, 0, totalSlots)
+	for ind, label := range parking.LotLabels(lotNos) {
+		turnover = append(turnover, SlotTurnover{Slot: label, Visits: visits[lotNos[ind]]})
+	}
+	return turnover
+}
+
+// reportCounts is local function for visits of each name sorted by the busiest
+func reportCounts(allocs []*Allocation, nameOf func(alloc *Allocation) string) []ReportCount {
+	counts := []ReportCount{}
+	indexes := map[string]int{}
+	for _, alloc := range allocs {
+		name := nameOf(alloc)
+		ind, ok := indexes[strings.ToLower(name)]
+		if !ok {
+			ind = len(counts)
+			indexes[strings.ToLower(name)] = ind
+			counts = append(counts, ReportCount{Name: name})
+		}
+		counts[ind].Visits++
+	}
+	sort.SliceStable(counts, func(i, j int) bool {
+		return counts[i].Visits > counts[j].Visits
+	})
+	return counts
+}
+
+// roundReportValue is local function for round value to 2 decimals
+func roundReportValue(value float64) float64 {
+	return math.Round(value*100) / 100
+}
+
+// Table is format print report
+func (report Report) Table(writer io.Writer) {
+	fmt.Fprintf(writer, "Report from %s to %s\n", report.From.Format(timeFormat), report.To.Format(timeFormat))
+	fmt.Fprintf(writer, "Visits: %d, average dwell: %.2f minutes\n", report.Visits, report.AverageDwellMinutes)
+	fmt.Fprintf(writer, "%-21s%s\n", "Time", "Occupancy")
+	for _, point := range report.Occupancy {
+		fmt.Fprintf(writer, "%-21s%.2f%%\n", point.Time.Format(timeFormat), point.Percentage)
+	}
+
+	peakHours := make([]string, 0, len(report.PeakHours))
+	for _, peakHour := range report.PeakHours {
+		peakHours = append(peakHours, fmt.Sprintf("%02d:00 (%.2f%%)", peakHour.Hour, peakHour.Percentage))
+	}
+	fmt.Fprintf(writer, "Peak hours: %s\n", strings.Join(peakHours, ", "))
+
+	turnover := make([]string, 0, len(report.Turnover))
+	for _, slotTurnover := range report.Turnover {
+		turnover = append(turnover, fmt.Sprintf("%s (%d)", slotTurnover.Slot, slotTurnover.Visits))
+	}
+	fmt.Fprintf(writer, "Turnover: %s\n", strings.Join(turnover, ", "))
+	fmt.Fprintf(writer, "Colours: %s\n", formatReportCounts(report.Colours))
+	fmt.Fprintf(writer, "Vehicle types: %s\n", formatReportCounts(report.VehicleTypes))
+}
+
+// formatReportCounts is local function for join names with their visits
+func formatReportCounts(counts []ReportCount) string {
+	names := make([]string, 0, len(counts))
+	for _, count := range counts {
+		names = append(names, fmt.Sprintf("%s (%d)", count.Name, count.Visits))
+	}
+	return strings.Join(names, ", ")
+}
+
+// WriteCSV is write report as CSV rows of section, name and value
+func (report Report) WriteCSV(writer io.Writer) error {
+	formatFloat := func(value float64) string {
+		return strconv.FormatFloat(value, 'f', 2, 64)
+	}
+
+	rows := [][]string{
+		{"section", "name", "value"},
+		{"summary", "from", report.From.Format(time.RFC3339)},
+		{"summary", "to", report.To.Format(time.RFC3339)},
+		{"summary", "total_slots", strconv.Itoa(report.TotalSlots)},
+		{"summary", "visits", strconv.Itoa(report.Visits)},
+		{"summary", "average_dwell_minutes", formatFloat(report.AverageDwellMinutes)},
+	}
+	for _, point := range report.Occupancy {
+		rows = append(rows, []string{"occupancy", point.Time.Format(time.RFC3339), formatFloat(point.Percentage)})
+	}
+	for _, peakHour := range report.PeakHours {
+		rows = append(rows, []string{"peak_hour", strconv.Itoa(peakHour.Hour), formatFloat(peakHour.Percentage)})
+	}
+	for _, slotTurnover := range report.Turnover {
+		rows = append(rows, []string{"turnover", slotTurnover.Slot, strconv.Itoa(slotTurnover.Visits)})
+	}
+	for _, count := range report.Colours {
+		rows = append(rows, []string{"colour", count.Name, strconv.Itoa(count.Visits)})
+	}
+	for _, count := range report.VehicleTypes {
+		rows = append(rows, []string{"vehicle_type", count.Name, strconv.Itoa(count.Visits)})
+	}
+
+	csvWriter := csv.NewWriter(writer)
+	csvWriter.WriteAll(rows)
+	return csvWriter.Error()
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"net/http"
+	"parkinglot/errmsgs"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testReportSitesHelper is parking sites with visits of 2 slots from 08:00 to 09:30
+// KA-01 08:00-09:00 slot 1, KA-02 08:30-09:30 slot 2, KA-03 from 09:15 slot 1 and still parking
+func testReportSitesHelper(clock *mockClock) IParkingSites {
+	parkingSites := NewParkingSitesWithClock(clock)
+	parking, _ := parkingSites.Create(defaultParkingSiteName)
+	parking.CreateParkingLot(2)
+	parking.Park(NewCar("KA-01", "White", 1))
+	clock.add(30 * time.Minute)
+	parking.Park(NewCar("KA-02", "Red", 1))
+	clock.add(30 * time.Minute)
+	parking.LeaveByPlateNo("KA-01")
+	clock.add(15 * time.Minute)
+	parking.Park(NewCar("KA-03", "white", 1))
+	clock.add(15 * time.Minute)
+	parking.LeaveByPlateNo("KA-02")
+	return parkingSites
+}
+
+func TestNewReportWithSuccess(t *testing.T) {
+	clock := newMockClock()
+	from := clock.now
+	parkingSites := testReportSitesHelper(clock)
+	parking, _ := parkingSites.Get(defaultParkingSiteName)
+
+	// KA-03 is still parking so it is counted until now 09:30 not until 10:00
+	report, err := NewReport(parking, from, from.Add(2*time.Hour))
+	if err != nil {
+		t.Fatalf("Error should be empty")
+	}
+	expected := Report{
+		From:                from,
+		To:                  from.Add(2 * time.Hour),
+		TotalSlots:          2,
+		Visits:              3,
+		AverageDwellMinutes: 60,
+		Occupancy: []OccupancyPoint{
+			{Time: from, Percentage: 75},
+			{Time: from.Add(time.Hour), Percentage: 37.5},
+		},
+		PeakHours:    []PeakHour{{Hour: 8, Percentage: 75}, {Hour: 9, Percentage: 37.5}},
+		Turnover:     []SlotTurnover{{Slot: "1", Visits: 2}, {Slot: "2", Visits: 1}},
+		Colours:      []ReportCount{{Name: "White", Visits: 2}, {Name: "Red", Visits: 1}},
+		VehicleTypes: []ReportCount{{Name: "car", Visits: 3}},
+	}
+	if !reflect.DeepEqual(report, expected) {
+		t.Errorf("Report should be\n%+v\nnot\n%+v", expected, report)
+	}
+
+	if report, _ := NewReport(parking, from.Add(-2*time.Hour), from); report.Visits != 1 || report.Occupancy[1].Percentage != 0 {
+		t.Errorf("Report before 08:00 should have only visit that park at 08:00 and no occupancy")
+	}
+	if report, _ := NewReport(parking, from.Add(time.Hour), from.Add(90*time.Minute)); report.Occupancy[0].Percentage != 75 {
+		t.Errorf("KA-03 should be counted until now in report that end at now")
+	}
+}
+
+func TestNewReportWithRangeInvalid(t *testing.T) {
+	clock := newMockClock()
+	parking := NewParkingWithClock(defaultParkingSiteName, clock)
+	from := clock.now
+
+	for _, to := range []time.Time{from, from.Add(-time.Hour), from.Add(reportMaxRange + time.Hour)} {
+		if _, err := NewReport(parking, from, to); err == nil || err.Error() != errmsgs.ReportRangeInvalidError().Error() {
+			t.Errorf("Report to %s should be range invalid", to)
+		}
+	}
+	if report, err := NewReport(parking, from, from.Add(reportMaxRange)); err != nil || len(report.Occupancy) != 366*24 {
+		t.Errorf("Report of 366 days should have %d occupancy points", 366*24)
+	}
+}
+
+func TestReportCommandWithSuccess(t *testing.T) {
+	parkingSites := testReportSitesHelper(newMockClock())
+	responses := testSnapshotCommandsHelper(parkingSites, strings.Join([]string{
+		"report 2020-09-28T08:00:00Z 2020-09-28T10:00:00Z --csv",
+		"report 2020-09-28T08:00:00Z 2020-09-28T09:00:00Z",
+		"report 2020-09-28T08:00:00Z",
+		"report 2020-09-28T08:00:00Z 2020-09-28T08:00:00Z",
+		"report 2020-01-01 2021-01-02",
+	}, "\n"))
+
+	expected := strings.Join([]string{
+		"section,name,value",
+		"summary,from,2020-09-28T08:00:00Z",
+		"summary,to,2020-09-28T10:00:00Z",
+		"summary,total_slots,2",
+		"summary,visits,3",
+		"summary,average_dwell_minutes,60.00",
+		"occupancy,2020-09-28T08:00:00Z,75.00",
+		"occupancy,2020-09-28T09:00:00Z,37.50",
+		"peak_hour,8,75.00",
+		"peak_hour,9,37.50",
+		"turnover,1,2",
+		"turnover,2,1",
+		"colour,White,2",
+		"colour,Red,1",
+		"vehicle_type,car,3",
+		"Report from 2020-09-28 08:00:00 to 2020-09-28 09:00:00",
+		"Visits: 2, average dwell: 60.00 minutes",
+		"Time                 Occupancy",
+		"2020-09-28 08:00:00  75.00%",
+		"Peak hours: 08:00 (75.00%)",
+		"Turnover: 1 (1), 2 (1)",
+		"Colours: White (1), Red (1)",
+		"Vehicle types: car (2)",
+		"Please input from and to time",
+		"Report range is invalid, to should be after from and within 366 days",
+		"Report range is invalid, to should be after from and within 366 days",
+		"",
+	}, "\n")
+	if responses != expected {
+		t.Errorf("Responses should be\n%s\nnot\n%s", expected, responses)
+	}
+}
+
+func TestHTTPReportWithSuccess(t *testing.T) {
+	server := NewHTTPServer(testReportSitesHelper(newMockClock()))
+
+	report := Report{}
+	if status := testRequestHelper(server, http.MethodGet, "/report?from=2020-09-28T08:00:00Z&to=2020-09-28T10:00:00Z", "", &report, t); status != http.StatusOK {
+		t.Errorf("Status should be ok not %d", status)
+	}
+	if report.Visits != 3 || len(report.Occupancy) != 2 || report.Occupancy[0].Percentage != 75 {
+		t.Errorf("Report should have 3 visits and 75%% occupancy at 08:00")
+	}
+
+	if status := testRequestHelper(server, http.MethodGet, "/report?from=2020-09-28T10:00:00Z&to=2020-09-28T08:00:00Z", "", nil, t); status != http.StatusBadRequest {
+		t.Errorf("Status should be bad request not %d", status)
+	}
+	if status := testRequestHelper(server, http.MethodGet, "/report?from=0001-01-01T00:00:00Z&to=9999-12-31T00:00:00Z", "", nil, t); status != http.StatusBadRequest {
+		t.Errorf("Status of too wide range should be bad request not %d", status)
+	}
+}
@@ -0,0 +1,210 @@
+package services
+
+import (
+	"parkinglot/models"
+	"sort"
+	"sync"
+)
+
+// IParkingRepository is repository that keep slots, vehicles and settings of one parking
+// Parking keep working state in memory for allocation and write each change to repository before method return
+// Change that repository can not write is undone in memory and its error is returned
+type IParkingRepository interface {
+	// Load is stored parking, nil is parking not stored
+	Load() (*ParkingSnapshot, error)
+	// Save is write settings with slots and tickets that changed
+	Save(changes ParkingChanges) error
+	// Replace is remove all stored slots and tickets then write changes
+	Replace(changes ParkingChanges) error
+}
+
+// ParkingChanges is settings of parking with slots and tickets that changed
+type ParkingChanges struct {
+	Settings ParkingSnapshot
+	Slots    []SlotRecord
+	Tickets  []TicketSnapshot
+}
+
+// SlotRecord is saved lot with label and status for repository that other tools read
+type SlotRecord struct {
+	SlotSnapshot
+	Label  string
+	Status models.ParkingStatus
+}
+
+// NewParkingWithRepository is a parking that load from repository and write each change to it
+// New parking is written to repository when repository has no parking
+func NewParkingWithRepository(name string, clock IClock, repository IParkingRepository) (IParking, error) {
+	snapshot, err := repository.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	var parking *Parking
+	if snapshot == nil {
+		parking = NewParkingWithClock(name, clock).(*Parking)
+	} else {
+		snapshot.Name = name
+		if parking, err = restoreParking(*snapshot, clock); err != nil {
+			return nil, err
+		}
+	}
+
+	parking.repository = repository
+	if snapshot == nil {
+		if err := parking.save(nil, nil); err != nil {
+			return nil, err
+		}
+	}
+	return parking, nil
+}
+
+// save is local function for write settings with lots and tickets to repository
+func (svc *Parking) save(lotNos []int, ticketIDs []string) error {
+	if svc.repository == nil {
+		return nil
+	}
+	return svc.repository.Save(svc.changes(lotNos, ticketIDs))
+}
+
+// changes is local function for copy settings, lots and tickets while parking is locked
+func (svc *Parking) changes(lotNos []int, ticketIDs []string) ParkingChanges {
+	changes := ParkingChanges{
+		Settings: svc.settingsSnapshot(),
+		Slots:    make([]SlotRecord, 0, len(lotNos)),
+		Tickets:  make([]TicketSnapshot, 0, len(ticketIDs)),
+	}
+	for _, lotNo := range lotNos {
+		if parkingLot, ok := svc.parkingLotKeyValue[lotNo]; ok {
+			changes.Slots = append(changes.Slots, SlotRecord{
+				SlotSnapshot: snapshotSlot(parkingLot),
+				Label:        parkingLot.Label(),
+				Status:       parkingLot.status,
+			})
+		}
+	}
+	for _, ticketID := range ticketIDs {
+		if alloc, ok := svc.tickets[ticketID]; ok {
+			changes.Tickets = append(changes.Tickets, snapshotTicket(alloc))
+		}
+	}
+	return changes
+}
+
+// allChanges is local function for copy settings with all lots and tickets
+func (svc *Parking) allChanges() ParkingChanges {
+	lotNos := make([]int, 0, len(svc.parkingLotKeyValue))
+	for lotNo := 1; lotNo <= len(svc.parkingLotKeyValue); lotNo++ {
+		lotNos = append(lotNos, lotNo)
+	}
+	ticketIDs := make([]string, 0, len(svc.tickets))
+	for ticketID := range svc.tickets {
+		ticketIDs = append(ticketIDs, ticketID)
+	}
+	sort.Strings(ticketIDs)
+	return svc.changes(lotNos, ticketIDs)
+}
+
+// MemoryRepository is repository that keep parking in memory of process
+// Parking that open the same repository again get the same state
+type MemoryRepository struct {
+	mutex sync.Mutex
+
+	// settings is nil when parking is not stored
+	settings *ParkingSnapshot
+	slots    map[int]SlotSnapshot
+	tickets  map[string]TicketSnapshot
+}
+
+// NewMemoryRepository is a new empty memory repository
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		slots:   map[int]SlotSnapshot{},
+		tickets: map[string]TicketSnapshot{},
+	}
+}
+
+// Load is stored parking, nil is parking not stored
+func (repository *MemoryRepository) Load() (*ParkingSnapshot, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	if repository.settings == nil {
+		return nil, nil
+	}
+	snapshot := repository.snapshot()
+	return &snapshot, nil
+}
+
+// snapshot is local function for stored parking with slots in lot no order and tickets in ticket order
+func (repository *MemoryRepository) snapshot() ParkingSnapshot {
+	snapshot := *repository.settings
+	snapshot.Slots = make([]SlotSnapshot, 0, len(repository.slots))
+	for _, slot := range repository.slots {
+		snapshot.Slots = append(snapshot.Slots, slot)
+	}
+	sort.Slice(snapshot.Slots, func(i, j int) bool {
+		return snapshot.Slots[i].LotNo < snapshot.Slots[j].LotNo
+	})
+
+	snapshot.Tickets = make([]TicketSnapshot, 0, len(repository.tickets))
+	for _, ticket := range repository.tickets {
+		snapshot.Tickets = append(snapshot.Tickets, ticket)
+	}
+	sort.Slice(snapshot.Tickets, func(i, j int) bool {
+		return snapshot.Tickets[i].Ticket < snapshot.Tickets[j].Ticket
+	})
+	return snapshot
+}
+
+// clone is local function for copy of repository that is changed without change this repository
+func (repository *MemoryRepository) clone() *MemoryRepository {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	cloned := NewMemoryRepository()
+	if repository.settings != nil {
+		settings := *repository.settings
+		cloned.settings = &settings
+	}
+	for lotNo, slot := range repository.slots {
+		cloned.slots[lotNo] = slot
+	}
+	for ticketID, ticket := range repository.tickets {
+		cloned.tickets[ticketID] = ticket
+	}
+	return cloned
+}
+
+// Save is write settings with slots and tickets that changed
+func (repository *MemoryRepository) Save(changes ParkingChanges) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+	repository.write(changes)
+	return nil
+}
+
+// Replace is remove all stored slots and tickets then write changes
+func (repository *MemoryRepository) Replace(changes ParkingChanges) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	repository.slots = map[int]SlotSnapshot{}
+	repository.tickets = map[string]TicketSnapshot{}
+	repository.write(changes)
+	return nil
+}
+
+// write is local function for keep settings, slots and tickets of changes
+func (repository *MemoryRepository) write(changes ParkingChanges) {
+	settings := changes.Settings
+	settings.Slots = nil
+	settings.Tickets = nil
+	repository.settings = &settings
+	for _, slot := range changes.Slots {
+		repository.slots[slot.LotNo] = slot.SlotSnapshot
+	}
+	for _, ticket := range changes.Tickets {
+		repository.tickets[ticket.Ticket] = ticket
+	}
+}
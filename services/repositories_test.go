@@ -0,0 +1,326 @@
+package services
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"parkinglot/models"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testFileStorageHelper is file storage in temp dir and function that remove it
+func testFileStorageHelper(t *testing.T) (string, *FileStorage, func()) {
+	dir, err := ioutil.TempDir("", "storage")
+	if err != nil {
+		t.Fatalf("Error should be empty")
+	}
+	storage, err := OpenFileStorage(dir)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatalf("Storage should be opened (%s)", err.Error())
+	}
+	return dir, storage, func() {
+		os.RemoveAll(dir)
+	}
+}
+
+func TestParkingConformanceWithMemoryRepository(t *testing.T) {
+	testParkingConformanceHelper(func(name string, clock IClock) IParking {
+		parking, err := NewParkingWithRepository(name, clock, NewMemoryRepository())
+		if err != nil {
+			t.Fatalf("Parking should be opened (%s)", err.Error())
+		}
+		return parking
+	}, t)
+}
+
+func TestParkingConformanceWithFile(t *testing.T) {
+	_, storage, removeStorage := testFileStorageHelper(t)
+	defer removeStorage()
+
+	testParkingConformanceHelper(func(name string, clock IClock) IParking {
+		parking, err := storage.Open(name, clock)
+		if err != nil {
+			t.Fatalf("Parking should be opened (%s)", err.Error())
+		}
+		return parking
+	}, t)
+}
+
+func TestFileParkingReopenWithSuccess(t *testing.T) {
+	dir, storage, removeStorage := testFileStorageHelper(t)
+	defer removeStorage()
+
+	testStorageReopenHelper(storage, func() IParkingStorage {
+		reopenedStorage, err := OpenFileStorage(dir)
+		if err != nil {
+			t.Fatalf("Storage should be opened (%s)", err.Error())
+		}
+		return reopenedStorage
+	}, t)
+}
+
+func TestMemoryRepositoryReopenWithSuccess(t *testing.T) {
+	clock := newMockClock()
+	repository := NewMemoryRepository()
+	parking, _ := NewParkingWithRepository(defaultParkingSiteName, clock, repository)
+	parking.CreateParkingLot(3)
+	parking.Park(NewCar("KA-01", "White", 1))
+	parking.Park(NewCar("KA-02", "Red", 1))
+	clock.add(time.Hour)
+	parking.Leave(1)
+	parking.Reserve(3, "KA-09")
+
+	reopenedParking, err := NewParkingWithRepository(defaultParkingSiteName, clock, repository)
+	if err != nil {
+		t.Fatalf("Parking should be opened (%s)", err.Error())
+	}
+	if !reflect.DeepEqual(reopenedParking.Snapshot(), parking.Snapshot()) {
+		t.Errorf("Reopened parking should be\n%+v\nnot\n%+v", parking.Snapshot(), reopenedParking.Snapshot())
+	}
+	if strings.Join(reopenedParking.LotLabels(reopenedParking.GetAllAvailableLotNos()), ",") != "1" {
+		t.Errorf("Available lots of reopened parking should be 1")
+	}
+}
+
+func TestRestoreParkingSitesInStorageWithSuccess(t *testing.T) {
+	dir, storage, removeStorage := testFileStorageHelper(t)
+	defer removeStorage()
+
+	parkingSites, _ := NewParkingSitesWithStorage(storage, newMockClock())
+	parking, _ := parkingSites.Create(defaultParkingSiteName)
+	parking.CreateParkingLot(4)
+	parking.Park(NewCar("KA-01", "White", 1))
+	parkingSites.Create("mall")
+
+	expectedSites := NewParkingSitesWithClock(newMockClock())
+	expectedParking, _ := expectedSites.Create(defaultParkingSiteName)
+	expectedParking.CreateParkingLot(2, LotCategoryRange{From: 2, To: 2, Category: models.LargeLot})
+	expectedParking.Park(NewCar("KA-02", "Red", 1))
+	expectedSites.Create("airport")
+
+	if err := parkingSites.Restore(expectedSites.Snapshot()); err != nil {
+		t.Fatalf("Parking sites should be restored (%s)", err.Error())
+	}
+
+	reopenedStorage, _ := OpenFileStorage(dir)
+	reopenedSites, err := NewParkingSitesWithStorage(reopenedStorage, newMockClock())
+	if err != nil {
+		t.Fatalf("Parking sites should be loaded (%s)", err.Error())
+	}
+	if !reflect.DeepEqual(reopenedSites.Snapshot(), expectedSites.Snapshot()) {
+		t.Errorf("Restored parking lots should be\n%+v\nnot\n%+v", expectedSites.Snapshot(), reopenedSites.Snapshot())
+	}
+	if names, _ := reopenedStorage.Names(); strings.Join(names, ",") != "parking-lot,airport" {
+		t.Errorf("Stored parking lots should be parking-lot,airport not %s", strings.Join(names, ","))
+	}
+}
+
+// testFailingRepository is memory repository that fail to write when isFailing is true
+type testFailingRepository struct {
+	*MemoryRepository
+	isFailing bool
+}
+
+func (repository *testFailingRepository) Save(changes ParkingChanges) error {
+	if repository.isFailing {
+		return errors.New("Repository is not written")
+	}
+	return repository.MemoryRepository.Save(changes)
+}
+
+func (repository *testFailingRepository) Replace(changes ParkingChanges) error {
+	if repository.isFailing {
+		return errors.New("Repository is not written")
+	}
+	return repository.MemoryRepository.Replace(changes)
+}
+
+func TestParkingRollbackWhenRepositoryFail(t *testing.T) {
+	clock := newMockClock()
+	repository := &testFailingRepository{MemoryRepository: NewMemoryRepository()}
+	parking, _ := NewParkingWithRepository(defaultParkingSiteName, clock, repository)
+	parking.CreateParkingLot(6)
+	strategy, _ := NewAllocationStrategy(models.RoundRobinStrategy, StrategyAttributes{})
+	parking.SetAllocationStrategy(strategy)
+	parking.Park(NewCar("KA-01", "White", 1))
+	parking.Park(NewCar("KA-02", "Red", 2))
+	parking.Reserve(5, "KA-09")
+	expected := parking.Snapshot()
+
+	repository.isFailing = true
+	if _, err := parking.Park(NewCar("KA-03", "Blue", 1)); err == nil {
+		t.Errorf("Park should be failed")
+	}
+	if _, err := parking.Park(NewCar("KA-09", "Blue", 1)); err == nil {
+		t.Errorf("Park at reserved lot should be failed")
+	}
+	if _, err := parking.Leave(1); err == nil {
+		t.Errorf("Leave should be failed")
+	}
+	if _, err := parking.LeaveByPlateNo("KA-02"); err == nil {
+		t.Errorf("Leave by registration number should be failed")
+	}
+	if _, err := parking.Reserve(4, "KA-08"); err == nil {
+		t.Errorf("Reserve should be failed")
+	}
+	if _, err := parking.Unreserve(5); err == nil {
+		t.Errorf("Unreserve should be failed")
+	}
+	if _, err := parking.CreateParkingLot(2); err == nil {
+		t.Errorf("Create parking lot should be failed")
+	}
+	if _, err := parking.CreateLevel(2); err == nil {
+		t.Errorf("Create level should be failed")
+	}
+	if _, err := parking.SetLevelPriority([]int{}); err == nil {
+		t.Errorf("Set level priority should be failed")
+	}
+	if _, err := parking.SetLotPreference(models.Truck, []models.LotCategory{models.CompactLot}); err == nil {
+		t.Errorf("Set slot preference should be failed")
+	}
+	if err := parking.SetAllocationStrategy(&farthestStrategy{}); err == nil {
+		t.Errorf("Set allocation strategy should be failed")
+	}
+	if err := parking.SetTariff(testTariffHelper(10)); err == nil {
+		t.Errorf("Set tariff should be failed")
+	}
+
+	if actual := parking.Snapshot(); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Parking should be\n%+v\nnot\n%+v", expected, actual)
+	}
+	if len(parking.Levels()) != 0 || parking.LotCount() != 6 || parking.AvailableLotCount() != 2 {
+		t.Errorf("Parking should have no level, 6 lots and 2 available lots")
+	}
+	if len(parking.GetParkingLotsWithCarColor("Blue")) != 0 || len(parking.GetParkingLotsWithPlateNo("KA-01")) != 1 {
+		t.Errorf("Vehicle indexes should be kept")
+	}
+
+	// Next park continue with ticket and round robin position as failed park did not happen
+	repository.isFailing = false
+	parkingLot, err := parking.Park(NewCar("KA-03", "Blue", 1))
+	if err != nil || parkingLot.lotNo != 4 {
+		t.Errorf("KA-03 should park at lot 4")
+	}
+	if alloc, _ := parking.LeaveByPlateNo("KA-03"); alloc == nil || alloc.TicketID() != "T000003" {
+		t.Errorf("Ticket of KA-03 should be T000003")
+	}
+	stored, _ := repository.Load()
+	if !reflect.DeepEqual(*stored, parking.Snapshot()) {
+		t.Errorf("Stored parking should be\n%+v\nnot\n%+v", parking.Snapshot(), *stored)
+	}
+}
+
+// testFailingStorage is storage of failing repositories that fail to remove failing name
+type testFailingStorage struct {
+	names        []string
+	repositories map[string]*testFailingRepository
+	failingName  string
+}
+
+func (storage *testFailingStorage) Names() ([]string, error) {
+	return storage.names, nil
+}
+
+func (storage *testFailingStorage) Open(name string, clock IClock) (IParking, error) {
+	repository, ok := storage.repositories[name]
+	if !ok {
+		repository = &testFailingRepository{MemoryRepository: NewMemoryRepository()}
+		storage.repositories[name] = repository
+		storage.names = append(storage.names, name)
+	}
+	return NewParkingWithRepository(name, clock, repository)
+}
+
+func (storage *testFailingStorage) Remove(name string) error {
+	if name == storage.failingName {
+		return errors.New("Storage is not written")
+	}
+	delete(storage.repositories, name)
+	names := []string{}
+	for _, storedName := range storage.names {
+		if storedName != name {
+			names = append(names, storedName)
+		}
+	}
+	storage.names = names
+	return nil
+}
+
+func TestRestoreParkingSitesWhenStorageFail(t *testing.T) {
+	storage := &testFailingStorage{repositories: map[string]*testFailingRepository{}}
+	parkingSites, _ := NewParkingSitesWithStorage(storage, newMockClock())
+	parking, _ := parkingSites.Create(defaultParkingSiteName)
+	parking.CreateParkingLot(4)
+	parking.Park(NewCar("KA-01", "White", 1))
+	parkingSites.Create("mall")
+	expected := parkingSites.Snapshot()
+	expectedStored, _ := storage.repositories[defaultParkingSiteName].Load()
+
+	restoredSites := NewParkingSitesWithClock(newMockClock())
+	restoredParking, _ := restoredSites.Create(defaultParkingSiteName)
+	restoredParking.CreateParkingLot(2)
+	restoredSites.Create("airport")
+
+	// Removing mall fail after parking-lot is written and airport is opened
+	storage.failingName = "mall"
+	if err := parkingSites.Restore(restoredSites.Snapshot()); err == nil {
+		t.Fatalf("Restore should be failed")
+	}
+	storage.failingName = ""
+	if actual := parkingSites.Snapshot(); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Parking sites should be\n%+v\nnot\n%+v", expected, actual)
+	}
+	if stored, _ := storage.repositories[defaultParkingSiteName].Load(); !reflect.DeepEqual(stored, expectedStored) {
+		t.Errorf("Stored parking-lot should be written back")
+	}
+	if strings.Join(storage.names, ",") != "parking-lot,mall" {
+		t.Errorf("Stored parking lots should be parking-lot,mall not %s", strings.Join(storage.names, ","))
+	}
+
+	// Repository that fail to write keep all parkings
+	storage.repositories[defaultParkingSiteName].isFailing = true
+	if err := parkingSites.Restore(restoredSites.Snapshot()); err == nil {
+		t.Fatalf("Restore should be failed")
+	}
+	if actual := parkingSites.Snapshot(); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Parking sites should be\n%+v\nnot\n%+v", expected, actual)
+	}
+	if strings.Join(storage.names, ",") != "parking-lot,mall" {
+		t.Errorf("Stored parking lots should be parking-lot,mall not %s", strings.Join(storage.names, ","))
+	}
+}
+
+func TestFileRepositoryNotKeepFailedChange(t *testing.T) {
+	dir, storage, removeStorage := testFileStorageHelper(t)
+	defer removeStorage()
+
+	clock := newMockClock()
+	parking, _ := storage.Open(defaultParkingSiteName, clock)
+	parking.CreateParkingLot(2)
+	parking.Park(NewCar("KA-01", "White", 1))
+
+	// Park fail while directory is missing, then leave is written after directory is back
+	os.RemoveAll(dir)
+	if _, err := parking.Park(NewCar("KA-02", "Red", 1)); err == nil {
+		t.Fatalf("Park should be failed")
+	}
+	os.MkdirAll(dir, 0755)
+	if _, err := parking.LeaveByPlateNo("KA-01"); err != nil {
+		t.Fatalf("Leave should be success (%s)", err.Error())
+	}
+
+	reopenedParking, err := NewParkingWithRepository(defaultParkingSiteName, clock, NewFileRepository(storage.path(defaultParkingSiteName)))
+	if err != nil {
+		t.Fatalf("Parking should be opened (%s)", err.Error())
+	}
+	if !reflect.DeepEqual(reopenedParking.Snapshot(), parking.Snapshot()) {
+		t.Errorf("Reopened parking should be\n%+v\nnot\n%+v", parking.Snapshot(), reopenedParking.Snapshot())
+	}
+	if len(reopenedParking.GetParkingLotsWithPlateNo("KA-02")) != 0 {
+		t.Errorf("KA-02 that failed to park should not be stored")
+	}
+}
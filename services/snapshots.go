@@ -0,0 +1,545 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"parkinglot/errmsgs"
+	"parkinglot/models"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// snapshotVersion is version of snapshot file format
+const snapshotVersion = 1
+
+// ParkingSitesSnapshot is saved state of all named parkings
+// Journal seq no is last command journal entry that included in snapshot
+type ParkingSitesSnapshot struct {
+	Version      int               `json:"version"`
+	JournalSeqNo int               `json:"journal_seq_no,omitempty"`
+	Lots         []ParkingSnapshot `json:"lots"`
+}
+
+// ParkingSnapshot is saved state of parking
+// Tickets keep vehicles that still parking and used tickets
+type ParkingSnapshot struct {
+	Name               string                                      `json:"name"`
+	Slots              []SlotSnapshot                              `json:"slots"`
+	LevelPriority      []int                                       `json:"level_priority,omitempty"`
+	SlotPreferences    map[models.VehicleType][]models.LotCategory `json:"slot_preferences"`
+	AllocationStrategy StrategySnapshot                            `json:"allocation_strategy"`
+	Tariff             *TariffConfig                               `json:"tariff,omitempty"`
+	TicketSeqNo        int                                         `json:"ticket_seq_no"`
+	Tickets            []TicketSnapshot                            `json:"tickets"`
+}
+
+// SlotSnapshot is saved lot, level 0 is lot that created without level
+type SlotSnapshot struct {
+	LotNo                      int                `json:"slot"`
+	Category                   models.LotCategory `json:"category"`
+	LevelNo                    int                `json:"level,omitempty"`
+	ReservedRegistrationNumber string             `json:"reserved_registration_number,omitempty"`
+}
+
+// StrategySnapshot is saved allocation strategy with its attributes and position
+// Last lot no of round robin and draws of random are kept so restored strategy choose the same next lots
+type StrategySnapshot struct {
+	Name      models.AllocationStrategy `json:"name"`
+	ExitLotNo int                       `json:"exit_slot,omitempty"`
+	Seed      int64                     `json:"seed,omitempty"`
+	LastLotNo int                       `json:"last_slot,omitempty"`
+	Draws     int64                     `json:"draws,omitempty"`
+}
+
+// TicketSnapshot is saved allocation, exit time is nil when vehicle still parking
+type TicketSnapshot struct {
+	Ticket             string             `json:"ticket"`
+	RegistrationNumber string             `json:"registration_number"`
+	Colour             string             `json:"colour"`
+	VehicleType        models.VehicleType `json:"vehicle_type"`
+	UsageLot           float32            `json:"size"`
+	PermitNo           string             `json:"permit,omitempty"`
+	Slots              []int              `json:"slots"`
+	EntryTime          time.Time          `json:"entry_time"`
+	ExitTime           *time.Time         `json:"exit_time,omitempty"`
+	Fee                float64            `json:"fee,omitempty"`
+}
+
+// SaveSnapshot is save state of parking sites to JSON file
+// Snapshot is written to temp file then renamed so old snapshot is kept when save fail
+func SaveSnapshot(parkingSitesSvc IParkingSites, path string) error {
+	return saveSnapshotFile(parkingSitesSvc.Snapshot(), path)
+}
+
+// saveSnapshotFile is local function for write snapshot to JSON file
+func saveSnapshotFile(snapshot ParkingSitesSnapshot, path string) error {
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return errmsgs.InternalServerError()
+	}
+	if err := writeFileAtomic(path, data); err != nil {
+		return fmt.Errorf("File is invalid (%s)", err.Error())
+	}
+	return nil
+}
+
+// writeFileAtomic is local function for write data to temp file then rename it to path
+// Old file is kept when write fail
+func writeFileAtomic(path string, data []byte) error {
+	tmpPath := path + ".tmp"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// LoadSnapshot is restore state of parking sites from JSON file
+func LoadSnapshot(parkingSitesSvc IParkingSites, path string) error {
+	snapshot, err := loadSnapshotFile(path)
+	if err != nil {
+		return err
+	}
+	return parkingSitesSvc.Restore(snapshot)
+}
+
+// loadSnapshotFile is local function for read snapshot from JSON file
+func loadSnapshotFile(path string) (ParkingSitesSnapshot, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return ParkingSitesSnapshot{}, fmt.Errorf("File is invalid (%s)", err.Error())
+	}
+	return parseSnapshot(data)
+}
+
+// parseSnapshot is local function for read snapshot from JSON data
+func parseSnapshot(data []byte) (ParkingSitesSnapshot, error) {
+	snapshot := ParkingSitesSnapshot{}
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return snapshot, errmsgs.SnapshotInvalidError()
+	}
+	return snapshot, nil
+}
+
+// Snapshot is saved state of all parkings in created order
+func (svc *ParkingSites) Snapshot() ParkingSitesSnapshot {
+	snapshot := ParkingSitesSnapshot{Version: snapshotVersion, Lots: []ParkingSnapshot{}}
+	for _, parking := range svc.Parkings() {
+		snapshot.Lots = append(snapshot.Lots, parking.Snapshot())
+	}
+	return snapshot
+}
+
+// Restore is replace all parkings with parkings in snapshot
+// Parking with same name is restored in place so selected parking and event subscribers are kept
+// All parkings are built and written to storage before they are swapped in,
+// nothing is changed when snapshot is invalid or storage can not write it
+func (svc *ParkingSites) Restore(snapshot ParkingSitesSnapshot) error {
+	if snapshot.Version != snapshotVersion {
+		return errmsgs.SnapshotInvalidError()
+	}
+
+	restoredParkings := map[string]*Parking{}
+	siteNames := make([]string, 0, len(snapshot.Lots))
+	for _, parkingSnapshot := range snapshot.Lots {
+		parking, err := restoreParking(parkingSnapshot, svc.clock)
+		if err != nil {
+			return err
+		}
+		if _, ok := restoredParkings[parking.name]; ok || parking.name == "" {
+			return errmsgs.SnapshotInvalidError()
+		}
+		restoredParkings[parking.name] = parking
+		siteNames = append(siteNames, parking.name)
+	}
+
+	svc.mutex.Lock()
+	defer svc.mutex.Unlock()
+
+	// undos put storage back when a later write fail, they are best effort because storage already fail
+	undos := []func(){}
+	rollback := func() {
+		for i := len(undos) - 1; i >= 0; i-- {
+			undos[i]()
+		}
+	}
+
+	// Existing parkings are restored in place, parkings of new names are opened in storage
+	targetParkings := map[string]*Parking{}
+	for _, name := range siteNames {
+		existingParking, ok := svc.parkings[name]
+		if !ok && svc.storage != nil {
+			storedParking, err := svc.storage.Open(name, svc.clock)
+			if err != nil {
+				rollback()
+				return err
+			}
+			name := name
+			undos = append(undos, func() { svc.storage.Remove(name) })
+			existingParking = storedParking
+		}
+		if targetParking, ok := existingParking.(*Parking); ok {
+			targetParkings[name] = targetParking
+		}
+	}
+
+	// Target parkings are locked until swap so changes between write and swap are not lost
+	for _, targetParking := range targetParkings {
+		targetParking.mutex.Lock()
+		defer targetParking.mutex.Unlock()
+	}
+	if err := svc.replaceStorage(targetParkings, restoredParkings, &undos); err != nil {
+		rollback()
+		return err
+	}
+
+	parkings := map[string]IParking{}
+	for name, parking := range restoredParkings {
+		if targetParking, ok := targetParkings[name]; ok {
+			targetParking.replace(parking)
+			parkings[name] = targetParking
+			continue
+		}
+		parkings[name] = parking
+	}
+	svc.parkings = parkings
+	svc.siteNames = siteNames
+	return nil
+}
+
+// replaceStorage is local function for write restored parkings to repositories of target parkings
+// and remove parkings that are not restored from storage, undo of each write is added to undos
+func (svc *ParkingSites) replaceStorage(targetParkings, restoredParkings map[string]*Parking, undos *[]func()) error {
+	for name, targetParking := range targetParkings {
+		if targetParking.repository == nil {
+			continue
+		}
+		repository, changes := targetParking.repository, targetParking.allChanges()
+		if err := repository.Replace(restoredParkings[name].allChanges()); err != nil {
+			return err
+		}
+		*undos = append(*undos, func() { repository.Replace(changes) })
+	}
+	if svc.storage == nil {
+		return nil
+	}
+
+	for _, name := range svc.siteNames {
+		if _, ok := restoredParkings[name]; ok {
+			continue
+		}
+		var changes *ParkingChanges
+		if parking, ok := svc.parkings[name].(*Parking); ok {
+			parking.mutex.RLock()
+			allChanges := parking.allChanges()
+			parking.mutex.RUnlock()
+			changes = &allChanges
+		}
+		if err := svc.storage.Remove(name); err != nil {
+			return err
+		}
+		name := name
+		*undos = append(*undos, func() {
+			storedParking, err := svc.storage.Open(name, svc.clock)
+			if err != nil || changes == nil {
+				return
+			}
+			if storedParking, ok := storedParking.(*Parking); ok && storedParking.repository != nil {
+				storedParking.repository.Replace(*changes)
+			}
+		})
+	}
+	return nil
+}
+
+// Snapshot is saved state of parking
+func (svc *Parking) Snapshot() ParkingSnapshot {
+	svc.mutex.RLock()
+	defer svc.mutex.RUnlock()
+
+	snapshot := svc.settingsSnapshot()
+	snapshot.Slots = make([]SlotSnapshot, 0, len(svc.parkingLotKeyValue))
+	for lotNo := 1; lotNo <= len(svc.parkingLotKeyValue); lotNo++ {
+		snapshot.Slots = append(snapshot.Slots, snapshotSlot(svc.parkingLotKeyValue[lotNo]))
+	}
+
+	ticketIDs := make([]string, 0, len(svc.tickets))
+	for ticketID := range svc.tickets {
+		ticketIDs = append(ticketIDs, ticketID)
+	}
+	sort.Strings(ticketIDs)
+	snapshot.Tickets = make([]TicketSnapshot, 0, len(svc.tickets))
+	for _, ticketID := range ticketIDs {
+		snapshot.Tickets = append(snapshot.Tickets, snapshotTicket(svc.tickets[ticketID]))
+	}
+	return snapshot
+}
+
+// settingsSnapshot is local function for saved state of parking without slots and tickets
+func (svc *Parking) settingsSnapshot() ParkingSnapshot {
+	snapshot := ParkingSnapshot{
+		Name:               svc.name,
+		LevelPriority:      append([]int(nil), svc.levelPriority...),
+		SlotPreferences:    map[models.VehicleType][]models.LotCategory{},
+		AllocationStrategy: snapshotStrategy(svc.strategy),
+		TicketSeqNo:        svc.ticketSeqNo,
+	}
+	for vehicleType, categories := range svc.lotPreferences {
+		snapshot.SlotPreferences[vehicleType] = append([]models.LotCategory(nil), categories...)
+	}
+	if parkingTariff, ok := svc.tariff.(*tariff); ok {
+		config := parkingTariff.config
+		snapshot.Tariff = &config
+	}
+	return snapshot
+}
+
+// snapshotSlot is local function for save lot
+func snapshotSlot(parkingLot *ParkingLot) SlotSnapshot {
+	return SlotSnapshot{
+		LotNo:                      parkingLot.lotNo,
+		Category:                   parkingLot.category,
+		LevelNo:                    parkingLot.levelNo,
+		ReservedRegistrationNumber: parkingLot.reservedPlateNo,
+	}
+}
+
+// snapshotStrategy is local function for save allocation strategy with its attributes
+func snapshotStrategy(strategy IAllocationStrategy) StrategySnapshot {
+	snapshot := StrategySnapshot{Name: strategy.Name()}
+	switch strategy := strategy.(type) {
+	case *closestExitStrategy:
+		snapshot.ExitLotNo = strategy.exitLotNo
+	case *roundRobinStrategy:
+		snapshot.LastLotNo = strategy.lastLotNo
+	case *randomStrategy:
+		snapshot.Seed = strategy.seed
+		snapshot.Draws = strategy.source.draws
+	}
+	return snapshot
+}
+
+// restoreStrategy is local function for build allocation strategy at saved position
+func restoreStrategy(snapshot StrategySnapshot) (IAllocationStrategy, error) {
+	strategy, err := NewAllocationStrategy(snapshot.Name, StrategyAttributes{
+		ExitLotNo: snapshot.ExitLotNo,
+		Seed:      snapshot.Seed,
+	})
+	if err != nil || snapshot.LastLotNo < 0 || snapshot.Draws < 0 {
+		return nil, errmsgs.SnapshotInvalidError()
+	}
+	switch strategy := strategy.(type) {
+	case *roundRobinStrategy:
+		strategy.lastLotNo = snapshot.LastLotNo
+	case *randomStrategy:
+		strategy.skip(snapshot.Draws)
+	}
+	return strategy, nil
+}
+
+// snapshotTicket is local function for save allocation
+func snapshotTicket(alloc *Allocation) TicketSnapshot {
+	snapshot := TicketSnapshot{
+		Ticket:             alloc.ticketID,
+		RegistrationNumber: alloc.vehicle.PlateNumber(),
+		Colour:             alloc.vehicle.Color(),
+		VehicleType:        alloc.vehicle.Type(),
+		UsageLot:           alloc.vehicle.UsageLot(),
+		Slots:              append([]int(nil), alloc.lotNos...),
+		EntryTime:          alloc.entryTime,
+		Fee:                alloc.fee,
+	}
+	if permitVehicle, ok := alloc.vehicle.(IPermitVehicle); ok {
+		snapshot.PermitNo = permitVehicle.PermitNo()
+	}
+	if !alloc.exitTime.IsZero() {
+		exitTime := alloc.exitTime
+		snapshot.ExitTime = &exitTime
+	}
+	return snapshot
+}
+
+// restoreParking is local function for build new parking from snapshot
+func restoreParking(snapshot ParkingSnapshot, clock IClock) (*Parking, error) {
+	parking := NewParkingWithClock(snapshot.Name, clock).(*Parking)
+
+	// Lots of each level are created together so level no is same or next level of previous lot
+	for i, slot := range snapshot.Slots {
+		if slot.LotNo != i+1 || !isValidLotCategory(slot.Category) || slot.LevelNo < 0 || slot.LevelNo > len(parking.levels)+1 {
+			return nil, errmsgs.SnapshotInvalidError()
+		}
+
+		parkingLot := newParkingLot(slot.LotNo, lotCategorySizes[slot.Category])
+		parkingLot.category = slot.Category
+		if slot.LevelNo != 0 {
+			if slot.LevelNo > len(parking.levels) {
+				parking.levels = append(parking.levels, newLevel(slot.LevelNo))
+			}
+			level := parking.levels[slot.LevelNo-1]
+			level.lotNos = append(level.lotNos, slot.LotNo)
+			parkingLot.levelNo = level.levelNo
+			parkingLot.levelLotNo = len(level.lotNos)
+		}
+		parking.parkingLotKeyValue[slot.LotNo] = parkingLot
+		parking.addAvailableLotNo(parkingLot)
+	}
+
+	for _, slot := range snapshot.Slots {
+		plateNo := slot.ReservedRegistrationNumber
+		if plateNo == "" {
+			continue
+		}
+		if _, ok := parking.reservedLotNos[plateNo]; ok {
+			return nil, errmsgs.SnapshotInvalidError()
+		}
+
+		parkingLot := parking.parkingLotKeyValue[slot.LotNo]
+		parking.removeAvailableLotNo(slot.LotNo)
+		parking.reservedLotNos[plateNo] = slot.LotNo
+		parkingLot.status = models.Reserve
+		parkingLot.reservedPlateNo = plateNo
+	}
+
+	// Ticket seq no can not be behind restored tickets, so next ticket never clash with them
+	maxTicketSeqNo := 0
+	for _, ticket := range snapshot.Tickets {
+		if err := parking.restoreTicket(ticket); err != nil {
+			return nil, err
+		}
+		if seqNo, ok := ticketSeqNoOf(ticket.Ticket); ok && seqNo > maxTicketSeqNo {
+			maxTicketSeqNo = seqNo
+		}
+	}
+	if snapshot.TicketSeqNo < maxTicketSeqNo {
+		return nil, errmsgs.SnapshotInvalidError()
+	}
+	parking.ticketSeqNo = snapshot.TicketSeqNo
+
+	if len(snapshot.LevelPriority) != 0 {
+		if _, err := parking.SetLevelPriority(snapshot.LevelPriority); err != nil {
+			return nil, errmsgs.SnapshotInvalidError()
+		}
+	}
+	for vehicleType, categories := range snapshot.SlotPreferences {
+		if _, err := parking.SetLotPreference(vehicleType, categories); err != nil {
+			return nil, errmsgs.SnapshotInvalidError()
+		}
+	}
+
+	if snapshot.AllocationStrategy.Name != "" {
+		if snapshot.AllocationStrategy.ExitLotNo > len(parking.parkingLotKeyValue) {
+			return nil, errmsgs.SnapshotInvalidError()
+		}
+		strategy, err := restoreStrategy(snapshot.AllocationStrategy)
+		if err != nil {
+			return nil, err
+		}
+		if err := parking.SetAllocationStrategy(strategy); err != nil {
+			return nil, err
+		}
+	}
+
+	if snapshot.Tariff != nil {
+		parkingTariff, err := NewTariff(*snapshot.Tariff)
+		if err != nil {
+			return nil, errmsgs.SnapshotInvalidError()
+		}
+		if err := parking.SetTariff(parkingTariff); err != nil {
+			return nil, err
+		}
+	}
+	return parking, nil
+}
+
+// restoreTicket is local function for restore allocation of ticket
+// Vehicle that still parking is placed back at its lots
+func (svc *Parking) restoreTicket(ticket TicketSnapshot) error {
+	vehicle, err := NewVehicle(ticket.VehicleType, ticket.RegistrationNumber, ticket.Colour, VehicleAttributes{
+		UsageLot: ticket.UsageLot,
+		PermitNo: ticket.PermitNo,
+	})
+	if err != nil || ticket.Ticket == "" || len(ticket.Slots) == 0 {
+		return errmsgs.SnapshotInvalidError()
+	}
+	if _, ok := svc.tickets[ticket.Ticket]; ok {
+		return errmsgs.SnapshotInvalidError()
+	}
+
+	alloc := newAllocation(ticket.Ticket, vehicle, ticket.Slots, ticket.EntryTime)
+	if ticket.ExitTime != nil {
+		alloc.exitTime = *ticket.ExitTime
+		alloc.fee = ticket.Fee
+		svc.tickets[ticket.Ticket] = alloc
+		return nil
+	}
+
+	if _, ok := svc.plateAllocations[vehicle.PlateNumber()]; ok {
+		return errmsgs.SnapshotInvalidError()
+	}
+	// Vehicle is placed only at lots that are free or busy lots that it can share like park
+	parkLots := make([]*ParkingLot, 0, len(ticket.Slots))
+	lotNos := map[int]struct{}{}
+	for _, lotNo := range ticket.Slots {
+		parkingLot, ok := svc.parkingLotKeyValue[lotNo]
+		if !ok || parkingLot.status == models.Reserve {
+			return errmsgs.SnapshotInvalidError()
+		}
+		if _, ok := lotNos[lotNo]; ok {
+			return errmsgs.SnapshotInvalidError()
+		}
+		if parkingLot.status == models.Busy && (len(ticket.Slots) > 1 || !parkingLot.isShareable(vehicle)) {
+			return errmsgs.SnapshotInvalidError()
+		}
+		lotNos[lotNo] = struct{}{}
+		parkLots = append(parkLots, parkingLot)
+	}
+	svc.tickets[ticket.Ticket] = alloc
+	svc.placeAllocation(parkLots, alloc)
+	return nil
+}
+
+// ticketSeqNoOf is local function for seq no of ticket id that parking issued, false is other ticket id
+func ticketSeqNoOf(ticketID string) (int, bool) {
+	if !strings.HasPrefix(ticketID, ticketIDPrefix) {
+		return 0, false
+	}
+	seqNo, err := strconv.Atoi(strings.TrimPrefix(ticketID, ticketIDPrefix))
+	if err != nil {
+		return 0, false
+	}
+	return seqNo, true
+}
+
+// replace is local function for replace state of parking with state of other parking
+// Name, clock, event subscribers and repository are kept, caller lock parking and write repository
+func (svc *Parking) replace(other *Parking) {
+	svc.parkingLotKeyValue = other.parkingLotKeyValue
+	svc.availableLots = other.availableLots
+	svc.segmentLots = other.segmentLots
+	svc.sharedLots = other.sharedLots
+	svc.reservedLotNos = other.reservedLotNos
+	svc.plateAllocations = other.plateAllocations
+	svc.colorAllocations = other.colorAllocations
+	svc.tickets = other.tickets
+	svc.ticketSeqNo = other.ticketSeqNo
+	svc.lotPreferences = other.lotPreferences
+	svc.levels = other.levels
+	svc.levelPriority = other.levelPriority
+	svc.tariff = other.tariff
+	svc.strategy = other.strategy
+}
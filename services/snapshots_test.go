@@ -0,0 +1,185 @@
+package services
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"parkinglot/errmsgs"
+	"parkinglot/models"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testSnapshotCommandsHelper is run commands with parking sites and return responses
+func testSnapshotCommandsHelper(parkingSites IParkingSites, commands string) string {
+	responses := &bytes.Buffer{}
+	parkingLot, err := parkingSites.Get(defaultParkingSiteName)
+	if err != nil {
+		parkingLot = nil
+	}
+	newParkingLotCommandInput(strings.NewReader(commands), responses, parkingSites, parkingLot).start()
+	return responses.String()
+}
+
+func TestSaveAndLoadSnapshotWithSuccess(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snapshots")
+	if err != nil {
+		t.Fatalf("Error should be empty")
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "parking.json")
+
+	clock := newMockClock()
+	parkingSites := NewParkingSitesWithClock(clock)
+	parkingSites.Create(defaultParkingSiteName)
+	testSnapshotCommandsHelper(parkingSites, strings.Join([]string{
+		"create_level 3 large:3",
+		"create_parking_lot 3",
+		"level_priority 1",
+		"allocation_strategy closest_exit 5",
+		"reserve 6 RES-1",
+		"park KA-01 White",
+		"park M-01 Black motorcycle",
+		"park M-02 Black motorcycle",
+		"park TR-01 Red truck",
+		"leave_by_registration KA-01",
+		"create_lot mall",
+		"create_parking_lot 1",
+		"park KA-02 Blue",
+	}, "\n"))
+	mall, _ := parkingSites.Get("mall")
+	hourlyRate := 10.0
+	tariff, _ := NewTariff(TariffConfig{TariffRule: TariffRule{HourlyRate: &hourlyRate}})
+	mall.SetTariff(tariff)
+
+	if err := SaveSnapshot(parkingSites, path); err != nil {
+		t.Fatalf("Snapshot should be saved (%s)", err.Error())
+	}
+
+	restoredSites := NewParkingSitesWithClock(clock)
+	if err := LoadSnapshot(restoredSites, path); err != nil {
+		t.Fatalf("Snapshot should be loaded (%s)", err.Error())
+	}
+	for _, name := range []string{defaultParkingSiteName, "mall"} {
+		parkingLot, _ := parkingSites.Get(name)
+		restoredLot, err := restoredSites.Get(name)
+		if err != nil {
+			t.Fatalf("Parking lot %s should be restored", name)
+		}
+		expected, restored := &bytes.Buffer{}, &bytes.Buffer{}
+		parkingLot.BusyStatusTable(expected, true)
+		restoredLot.BusyStatusTable(restored, true)
+		if restored.String() != expected.String() {
+			t.Errorf("Status of %s should be\n%s\nnot\n%s", name, expected.String(), restored.String())
+		}
+		if strings.Join(restoredLot.LotLabels(restoredLot.GetAllAvailableLotNos()), ",") != strings.Join(parkingLot.LotLabels(parkingLot.GetAllAvailableLotNos()), ",") {
+			t.Errorf("Available lots of %s should be same", name)
+		}
+	}
+
+	restoredLot, _ := restoredSites.Get(defaultParkingSiteName)
+	if restoredLot.AllocationStrategy().Name() != models.ClosestExitStrategy {
+		t.Errorf("Allocation strategy should be closest exit")
+	}
+	if _, err := restoredLot.LeaveByTicket("T000001"); !(err != nil && err.Error() == errmsgs.TicketAlreadyUsedError().Error()) {
+		t.Errorf("Error should have and should be ticket was already used")
+	}
+	if _, err := restoredLot.Park(NewCar("M-01", "Black", 1)); !(err != nil && err.Error() == errmsgs.VehicalAlreadyParkingError().Error()) {
+		t.Errorf("Error should have and should be vehical already parking")
+	}
+	restoredLot.Park(NewCar("RES-1", "Grey", 1))
+	if parkingLots := restoredLot.GetParkingLotsWithPlateNo("RES-1"); len(parkingLots) != 1 || parkingLots[0].lotNo != 6 {
+		t.Errorf("Vehicle RES-1 should park at reserved slot 6")
+	}
+	if allocation, _ := restoredLot.LeaveByPlateNo("RES-1"); allocation == nil || allocation.TicketID() != "T000005" {
+		t.Errorf("Ticket of RES-1 should be T000005")
+	}
+
+	clock.add(90 * time.Minute)
+	restoredMall, _ := restoredSites.Get("mall")
+	if allocation, _ := restoredMall.LeaveByPlateNo("KA-02"); allocation == nil || allocation.Fee() != 20 {
+		t.Errorf("Fee of KA-02 should be 20")
+	}
+}
+
+func TestLoadSnapshotWithInputError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snapshots")
+	if err != nil {
+		t.Fatalf("Error should be empty")
+	}
+	defer os.RemoveAll(dir)
+
+	parkingSites := NewParkingSitesWithClock(newMockClock())
+	parkingSites.Create(defaultParkingSiteName)
+	if err := LoadSnapshot(parkingSites, filepath.Join(dir, "not-found.json")); err == nil {
+		t.Errorf("Snapshot should not be loaded")
+	}
+
+	testCases := []string{
+		`{"version": 1, "lots": [{"name": "a", "slots": [{"slot": 2, "category": "compact"}]}]}`,
+		`{"version": 1, "lots": [{"name": "a", "slots": [{"slot": 1, "category": "huge"}]}]}`,
+		`{"version": 1, "lots": [{"name": "a", "slots": []}, {"name": "a", "slots": []}]}`,
+		`{"version": 1, "lots": [{"name": "a", "slots": [{"slot": 1, "category": "compact"}], "tickets": [{"ticket": "T000001", "registration_number": "A", "vehicle_type": "car", "slots": [2]}]}]}`,
+		`{"version": 1, "lots": [{"name": "a", "slots": [{"slot": 1, "category": "compact"}, {"slot": 2, "category": "compact"}], "ticket_seq_no": 1, "tickets": [{"ticket": "T000001", "registration_number": "A", "vehicle_type": "truck", "slots": [1, 1]}]}]}`,
+		`{"version": 1, "lots": [{"name": "a", "slots": [{"slot": 1, "category": "compact"}], "ticket_seq_no": 2, "tickets": [{"ticket": "T000001", "registration_number": "A", "vehicle_type": "car", "slots": [1]}, {"ticket": "T000002", "registration_number": "B", "vehicle_type": "motorcycle", "slots": [1]}]}]}`,
+		`{"version": 1, "lots": [{"name": "a", "slots": [{"slot": 1, "category": "compact"}], "ticket_seq_no": 1, "tickets": [{"ticket": "T000002", "registration_number": "A", "vehicle_type": "car", "slots": [1]}]}]}`,
+		`{"version": 1, "lots": [{"name": "a", "slots": [], "allocation_strategy": {"name": "shortest"}}]}`,
+		`{"version": 2, "lots": []}`,
+		`{"version": 1, "lots": [`,
+	}
+	for _, testCase := range testCases {
+		path := filepath.Join(dir, "invalid.json")
+		ioutil.WriteFile(path, []byte(testCase), 0644)
+		if err := LoadSnapshot(parkingSites, path); !(err != nil && err.Error() == errmsgs.SnapshotInvalidError().Error()) {
+			t.Errorf("Error of %s should be snapshot is invalid", testCase)
+		}
+	}
+	if _, err := parkingSites.Get(defaultParkingSiteName); err != nil {
+		t.Errorf("Parking lots should not be changed by invalid snapshot")
+	}
+
+	path := filepath.Join(dir, "shared.json")
+	ioutil.WriteFile(path, []byte(`{"version": 1, "lots": [{"name": "a", "slots": [{"slot": 1, "category": "compact"}], "ticket_seq_no": 2, "tickets": [`+
+		`{"ticket": "T000001", "registration_number": "A", "vehicle_type": "motorcycle", "slots": [1]}, `+
+		`{"ticket": "T000002", "registration_number": "B", "vehicle_type": "motorcycle", "slots": [1]}]}]}`), 0644)
+	if err := LoadSnapshot(parkingSites, path); err != nil {
+		t.Errorf("Snapshot with motorcycles that share lot should be loaded")
+	}
+}
+
+func TestSaveAndLoadCommandsWithSuccess(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snapshots")
+	if err != nil {
+		t.Fatalf("Error should be empty")
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "parking.json")
+
+	parkingSites := NewParkingSitesWithClock(newMockClock())
+	parkingSites.Create(defaultParkingSiteName)
+	responses := testSnapshotCommandsHelper(parkingSites, strings.Join([]string{
+		"create_parking_lot 2",
+		"park KA-01 White",
+		"save " + path,
+		"park KA-02 White",
+		"load " + path,
+		"status",
+		"load",
+	}, "\n"))
+
+	expected := strings.Join([]string{
+		"Created a parking lot with 2 slots",
+		"Allocated slot number: 1",
+		"Saved parking lots to " + path,
+		"Allocated slot number: 2",
+		"Loaded parking lots from " + path,
+		"Slot No.    Registration No    Colour",
+		"1           KA-01              White",
+		"Please input snapshot file",
+	}, "\n") + "\n"
+	if responses != expected {
+		t.Errorf("Responses should be\n%s\nnot\n%s", expected, responses)
+	}
+}
@@ -0,0 +1,310 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	// sqlite3 is database driver of SQLite storage
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// sqliteSchema is tables of SQLite storage
+// Tickets keep vehicles that still parking (exit time is null) and history of vehicles that left
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS parking_lots (
+	name     TEXT PRIMARY KEY,
+	settings TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS slots (
+	lot                          TEXT    NOT NULL,
+	slot                         INTEGER NOT NULL,
+	label                        TEXT    NOT NULL,
+	category                     TEXT    NOT NULL,
+	level                        INTEGER NOT NULL,
+	status                       TEXT    NOT NULL,
+	reserved_registration_number TEXT    NOT NULL,
+	PRIMARY KEY (lot, slot)
+);
+CREATE TABLE IF NOT EXISTS tickets (
+	lot                 TEXT NOT NULL,
+	ticket              TEXT NOT NULL,
+	registration_number TEXT NOT NULL,
+	colour              TEXT NOT NULL,
+	vehicle_type        TEXT NOT NULL,
+	size                REAL NOT NULL,
+	permit              TEXT NOT NULL,
+	slots               TEXT NOT NULL,
+	entry_time          TEXT NOT NULL,
+	exit_time           TEXT,
+	fee                 REAL NOT NULL,
+	PRIMARY KEY (lot, ticket)
+);`
+
+// sqliteTimeFormat is format of time in database
+const sqliteTimeFormat = time.RFC3339Nano
+
+// SQLiteStorage is storage that keep parkings in SQLite database file
+// Other processes can read the same database file while parking lot is running
+type SQLiteStorage struct {
+	db *sql.DB
+}
+
+// OpenSQLiteStorage is open SQLite database file and create tables that not exist
+func OpenSQLiteStorage(path string) (*SQLiteStorage, error) {
+	db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?_busy_timeout=5000&_journal_mode=WAL", path))
+	if err != nil {
+		return nil, fmt.Errorf("Database is invalid (%s)", err.Error())
+	}
+	// Changes are written one at a time so database is never locked by this process
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("Database is invalid (%s)", err.Error())
+	}
+	return &SQLiteStorage{db: db}, nil
+}
+
+// Close is close database file
+func (storage *SQLiteStorage) Close() error {
+	return storage.db.Close()
+}
+
+// Names is names of stored parkings in created order
+func (storage *SQLiteStorage) Names() ([]string, error) {
+	rows, err := storage.db.Query("SELECT name FROM parking_lots ORDER BY rowid")
+	if err != nil {
+		return nil, fmt.Errorf("Database is not read (%s)", err.Error())
+	}
+	defer rows.Close()
+
+	names := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("Database is not read (%s)", err.Error())
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// Open is load stored parking or create new one when name is not stored
+func (storage *SQLiteStorage) Open(name string, clock IClock) (IParking, error) {
+	return NewParkingWithRepository(name, clock, &sqliteRepository{db: storage.db, name: name})
+}
+
+// Remove is remove stored parking with its slots and tickets
+func (storage *SQLiteStorage) Remove(name string) error {
+	tx, err := storage.db.Begin()
+	if err != nil {
+		return fmt.Errorf("Database is not written (%s)", err.Error())
+	}
+	if _, err := tx.Exec("DELETE FROM parking_lots WHERE name = ?", name); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("Database is not written (%s)", err.Error())
+	}
+	if err := deleteSQLiteRows(tx, name); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("Database is not written (%s)", err.Error())
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("Database is not written (%s)", err.Error())
+	}
+	return nil
+}
+
+// sqliteRepository is repository that keep parking in rows of SQLite database
+// Tickets of vehicles that still parking have null exit time, others are history
+type sqliteRepository struct {
+	db   *sql.DB
+	name string
+}
+
+// Load is stored parking, nil is parking not stored
+func (repository *sqliteRepository) Load() (*ParkingSnapshot, error) {
+	var settings string
+	err := repository.db.QueryRow("SELECT settings FROM parking_lots WHERE name = ?", repository.name).Scan(&settings)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Database is not read (%s)", err.Error())
+	}
+
+	snapshot := ParkingSnapshot{}
+	if err := json.Unmarshal([]byte(settings), &snapshot); err != nil {
+		return nil, fmt.Errorf("Database is not read (%s)", err.Error())
+	}
+	snapshot.Name = repository.name
+	if snapshot.Slots, err = repository.loadSlots(); err != nil {
+		return nil, err
+	}
+	if snapshot.Tickets, err = repository.loadTickets(); err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// loadSlots is local function for read slots of parking in lot no order
+func (repository *sqliteRepository) loadSlots() ([]SlotSnapshot, error) {
+	rows, err := repository.db.Query("SELECT slot, category, level, reserved_registration_number FROM slots WHERE lot = ? ORDER BY slot", repository.name)
+	if err != nil {
+		return nil, fmt.Errorf("Database is not read (%s)", err.Error())
+	}
+	defer rows.Close()
+
+	slots := []SlotSnapshot{}
+	for rows.Next() {
+		slot := SlotSnapshot{}
+		if err := rows.Scan(&slot.LotNo, &slot.Category, &slot.LevelNo, &slot.ReservedRegistrationNumber); err != nil {
+			return nil, fmt.Errorf("Database is not read (%s)", err.Error())
+		}
+		slots = append(slots, slot)
+	}
+	return slots, rows.Err()
+}
+
+// loadTickets is local function for read tickets of parking in ticket order
+func (repository *sqliteRepository) loadTickets() ([]TicketSnapshot, error) {
+	rows, err := repository.db.Query(`SELECT ticket, registration_number, colour, vehicle_type, size, permit, slots, entry_time, exit_time, fee
+		FROM tickets WHERE lot = ? ORDER BY ticket`, repository.name)
+	if err != nil {
+		return nil, fmt.Errorf("Database is not read (%s)", err.Error())
+	}
+	defer rows.Close()
+
+	tickets := []TicketSnapshot{}
+	for rows.Next() {
+		var (
+			ticket    TicketSnapshot
+			lotNos    string
+			entryTime string
+			exitTime  sql.NullString
+		)
+		err := rows.Scan(&ticket.Ticket, &ticket.RegistrationNumber, &ticket.Colour, &ticket.VehicleType, &ticket.UsageLot,
+			&ticket.PermitNo, &lotNos, &entryTime, &exitTime, &ticket.Fee)
+		if err != nil {
+			return nil, fmt.Errorf("Database is not read (%s)", err.Error())
+		}
+		if ticket.Slots, err = parseSQLiteLotNos(lotNos); err != nil {
+			return nil, fmt.Errorf("Database is not read (%s)", err.Error())
+		}
+		if ticket.EntryTime, err = time.Parse(sqliteTimeFormat, entryTime); err != nil {
+			return nil, fmt.Errorf("Database is not read (%s)", err.Error())
+		}
+		if exitTime.Valid {
+			parsedExitTime, err := time.Parse(sqliteTimeFormat, exitTime.String)
+			if err != nil {
+				return nil, fmt.Errorf("Database is not read (%s)", err.Error())
+			}
+			ticket.ExitTime = &parsedExitTime
+		}
+		tickets = append(tickets, ticket)
+	}
+	return tickets, rows.Err()
+}
+
+// parseSQLiteLotNos is local function for convert comma separated lot nos of ticket
+func parseSQLiteLotNos(lotNos string) ([]int, error) {
+	parsedLotNos := []int{}
+	for _, lotNo := range strings.Split(lotNos, ",") {
+		parsedLotNo, err := strconv.Atoi(lotNo)
+		if err != nil {
+			return nil, err
+		}
+		parsedLotNos = append(parsedLotNos, parsedLotNo)
+	}
+	return parsedLotNos, nil
+}
+
+// Save is write settings with slots and tickets that changed in one transaction
+func (repository *sqliteRepository) Save(changes ParkingChanges) error {
+	return repository.write(changes, false)
+}
+
+// Replace is remove all stored slots and tickets then write changes in one transaction
+func (repository *sqliteRepository) Replace(changes ParkingChanges) error {
+	return repository.write(changes, true)
+}
+
+// write is local function for write rows in one transaction, old lots and tickets are removed when isRewrite
+func (repository *sqliteRepository) write(changes ParkingChanges, isRewrite bool) error {
+	settings := changes.Settings
+	settings.Slots = nil
+	settings.Tickets = nil
+	settingsData, err := json.Marshal(settings)
+	if err != nil {
+		return fmt.Errorf("Database is not written (%s)", err.Error())
+	}
+
+	tx, err := repository.db.Begin()
+	if err != nil {
+		return fmt.Errorf("Database is not written (%s)", err.Error())
+	}
+	if isRewrite {
+		if err := deleteSQLiteRows(tx, repository.name); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("Database is not written (%s)", err.Error())
+		}
+	}
+	if err := writeSQLiteRows(tx, repository.name, string(settingsData), changes.Slots, changes.Tickets); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("Database is not written (%s)", err.Error())
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("Database is not written (%s)", err.Error())
+	}
+	return nil
+}
+
+// writeSQLiteRows is local function for upsert rows of parking in transaction
+func writeSQLiteRows(tx *sql.Tx, name, settings string, slots []SlotRecord, tickets []TicketSnapshot) error {
+	_, err := tx.Exec(`INSERT INTO parking_lots (name, settings) VALUES (?, ?)
+		ON CONFLICT (name) DO UPDATE SET settings = excluded.settings`, name, settings)
+	if err != nil {
+		return err
+	}
+
+	for _, slot := range slots {
+		_, err := tx.Exec(`INSERT OR REPLACE INTO slots (lot, slot, label, category, level, status, reserved_registration_number)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			name, slot.LotNo, slot.Label, slot.Category, slot.LevelNo, slot.Status, slot.ReservedRegistrationNumber)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, ticket := range tickets {
+		lotNos := make([]string, 0, len(ticket.Slots))
+		for _, lotNo := range ticket.Slots {
+			lotNos = append(lotNos, strconv.Itoa(lotNo))
+		}
+		var exitTime interface{}
+		if ticket.ExitTime != nil {
+			exitTime = ticket.ExitTime.Format(sqliteTimeFormat)
+		}
+		_, err := tx.Exec(`INSERT OR REPLACE INTO tickets
+			(lot, ticket, registration_number, colour, vehicle_type, size, permit, slots, entry_time, exit_time, fee)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			name, ticket.Ticket, ticket.RegistrationNumber, ticket.Colour, ticket.VehicleType, ticket.UsageLot,
+			ticket.PermitNo, strings.Join(lotNos, ","), ticket.EntryTime.Format(sqliteTimeFormat), exitTime, ticket.Fee)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteSQLiteRows is local function for delete lots and tickets of parking in transaction
+func deleteSQLiteRows(tx *sql.Tx, name string) error {
+	for _, query := range []string{"DELETE FROM slots WHERE lot = ?", "DELETE FROM tickets WHERE lot = ?"} {
+		if _, err := tx.Exec(query, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+//go:build cgo
+// +build cgo
+
+package services
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// SQLite driver need cgo, these tests are not built when CGO_ENABLED=0
+
+// testSQLiteStorageHelper is SQLite storage in temp dir and function that remove it
+func testSQLiteStorageHelper(t *testing.T) (string, *SQLiteStorage, func()) {
+	dir, err := ioutil.TempDir("", "sqlite")
+	if err != nil {
+		t.Fatalf("Error should be empty")
+	}
+	path := filepath.Join(dir, "parking.db")
+	storage, err := OpenSQLiteStorage(path)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatalf("Storage should be opened (%s)", err.Error())
+	}
+	return path, storage, func() {
+		storage.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestParkingConformanceWithSQLite(t *testing.T) {
+	_, storage, closeStorage := testSQLiteStorageHelper(t)
+	defer closeStorage()
+
+	testParkingConformanceHelper(func(name string, clock IClock) IParking {
+		parking, err := storage.Open(name, clock)
+		if err != nil {
+			t.Fatalf("Parking should be opened (%s)", err.Error())
+		}
+		return parking
+	}, t)
+}
+
+func TestSQLiteParkingReopenWithSuccess(t *testing.T) {
+	path, storage, closeStorage := testSQLiteStorageHelper(t)
+	defer closeStorage()
+
+	var reopenedStorage *SQLiteStorage
+	testStorageReopenHelper(storage, func() IParkingStorage {
+		var err error
+		if reopenedStorage, err = OpenSQLiteStorage(path); err != nil {
+			t.Fatalf("Storage should be opened (%s)", err.Error())
+		}
+		return reopenedStorage
+	}, t)
+	if reopenedStorage != nil {
+		reopenedStorage.Close()
+	}
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"parkinglot/errmsgs"
+	"parkinglot/models"
+	"time"
+)
+
+const (
+	// nightTimeFormat is format of night rate from and to time
+	nightTimeFormat = "15:04"
+	// dayDuration is period that daily cap is applied
+	dayDuration = 24 * time.Hour
+)
+
+// ITariff is fee calculator of parking
+type ITariff interface {
+	Fee(vehicle IVehicle, category models.LotCategory, entryTime, exitTime time.Time) float64
+}
+
+// TariffConfig is tariff config file format
+// Vehicle type rule and slot category rule override base rule
+type TariffConfig struct {
+	TariffRule
+	VehicleTypes   map[models.VehicleType]TariffRule `json:"vehicle_types"`
+	SlotCategories map[models.LotCategory]TariffRule `json:"slot_categories"`
+}
+
+// TariffRule is rates of tariff, empty field is use rate of base rule
+type TariffRule struct {
+	HourlyRate  *float64   `json:"hourly_rate"`
+	FreeMinutes *int       `json:"free_minutes"`
+	DailyCap    *float64   `json:"daily_cap"`
+	NightRate   *NightRate `json:"night_rate"`
+}
+
+// NightRate is hourly rate between from and to time (e.g. 22:00 to 06:00)
+type NightRate struct {
+	From       string  `json:"from"`
+	To         string  `json:"to"`
+	HourlyRate float64 `json:"hourly_rate"`
+}
+
+// merge is override rule fields with fields that set in other rule
+func (rule TariffRule) merge(other TariffRule) TariffRule {
+	if other.HourlyRate != nil {
+		rule.HourlyRate = other.HourlyRate
+	}
+	if other.FreeMinutes != nil {
+		rule.FreeMinutes = other.FreeMinutes
+	}
+	if other.DailyCap != nil {
+		rule.DailyCap = other.DailyCap
+	}
+	if other.NightRate != nil {
+		rule.NightRate = other.NightRate
+	}
+	return rule
+}
+
+// tariff is tariff that calculate fee by started hour
+type tariff struct {
+	config TariffConfig
+}
+
+// NewTariff is a new tariff from config
+func NewTariff(config TariffConfig) (ITariff, error) {
+	rules := []TariffRule{config.TariffRule}
+	for _, rule := range config.VehicleTypes {
+		rules = append(rules, rule)
+	}
+	for _, rule := range config.SlotCategories {
+		rules = append(rules, rule)
+	}
+	for _, rule := range rules {
+		if !rule.isValid() {
+			return nil, errmsgs.TariffConfigInvalidError()
+		}
+	}
+	return &tariff{config: config}, nil
+}
+
+// LoadTariff is a new tariff from JSON config file
+func LoadTariff(path string) (ITariff, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("File is invalid (%s)", err.Error())
+	}
+	return ParseTariff(data)
+}
+
+// ParseTariff is a new tariff from JSON config data
+func ParseTariff(data []byte) (ITariff, error) {
+	config := TariffConfig{}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, errmsgs.TariffConfigInvalidError()
+	}
+	return NewTariff(config)
+}
+
+// Fee is calculate fee of parking from entry time to exit time
+// Free minutes are not charged, then every started hour is charged
+// with night rate or hourly rate and every day is capped by daily cap
+func (svc *tariff) Fee(vehicle IVehicle, category models.LotCategory, entryTime, exitTime time.Time) float64 {
+	rule := svc.config.TariffRule
+	if vehicle != nil {
+		rule = rule.merge(svc.config.VehicleTypes[vehicle.Type()])
+	}
+	rule = rule.merge(svc.config.SlotCategories[category])
+
+	chargeTime := entryTime
+	if rule.FreeMinutes != nil {
+		chargeTime = entryTime.Add(time.Duration(*rule.FreeMinutes) * time.Minute)
+	}
+	if !exitTime.After(chargeTime) {
+		return 0
+	}
+
+	dayFees := map[int]float64{}
+	for hourTime := chargeTime; hourTime.Before(exitTime); hourTime = hourTime.Add(time.Hour) {
+		day := int(hourTime.Sub(entryTime) / dayDuration)
+		dayFees[day] += rule.hourlyRate(hourTime)
+	}
+
+	var fee float64
+	for _, dayFee := range dayFees {
+		if rule.DailyCap != nil && dayFee > *rule.DailyCap {
+			dayFee = *rule.DailyCap
+		}
+		fee += dayFee
+	}
+	return math.Round(fee*100) / 100
+}
+
+// hourlyRate is rate of hour that start at hour time
+func (rule TariffRule) hourlyRate(hourTime time.Time) float64 {
+	if rule.NightRate != nil && rule.NightRate.isNight(hourTime) {
+		return rule.NightRate.HourlyRate
+	}
+	if rule.HourlyRate == nil {
+		return 0
+	}
+	return *rule.HourlyRate
+}
+
+// isValid is check rates are not negative and night time is valid
+func (rule TariffRule) isValid() bool {
+	if rule.HourlyRate != nil && *rule.HourlyRate < 0 {
+		return false
+	}
+	if rule.FreeMinutes != nil && *rule.FreeMinutes < 0 {
+		return false
+	}
+	if rule.DailyCap != nil && *rule.DailyCap < 0 {
+		return false
+	}
+	if rule.NightRate == nil {
+		return true
+	}
+	if _, err := time.Parse(nightTimeFormat, rule.NightRate.From); err != nil {
+		return false
+	}
+	if _, err := time.Parse(nightTimeFormat, rule.NightRate.To); err != nil {
+		return false
+	}
+	return rule.NightRate.HourlyRate >= 0
+}
+
+// isNight is check time is between from and to time
+// From time after to time is mean night rate cross midnight
+func (night *NightRate) isNight(hourTime time.Time) bool {
+	from, _ := time.Parse(nightTimeFormat, night.From)
+	to, _ := time.Parse(nightTimeFormat, night.To)
+	fromMinutes := from.Hour()*60 + from.Minute()
+	toMinutes := to.Hour()*60 + to.Minute()
+	minutes := hourTime.Hour()*60 + hourTime.Minute()
+
+	if fromMinutes <= toMinutes {
+		return minutes >= fromMinutes && minutes < toMinutes
+	}
+	return minutes >= fromMinutes || minutes < toMinutes
+}
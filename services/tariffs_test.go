@@ -0,0 +1,120 @@
+package services
+
+import (
+	"io/ioutil"
+	"os"
+	"parkinglot/errmsgs"
+	"parkinglot/models"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestTariff(t *testing.T) ITariff {
+	hourlyRate, freeMinutes, dailyCap := 20.0, 15, 100.0
+	truckRate, chargingRate := 40.0, 30.0
+	tariff, err := NewTariff(TariffConfig{
+		TariffRule: TariffRule{
+			HourlyRate:  &hourlyRate,
+			FreeMinutes: &freeMinutes,
+			DailyCap:    &dailyCap,
+			NightRate:   &NightRate{From: "22:00", To: "06:00", HourlyRate: 10},
+		},
+		VehicleTypes: map[models.VehicleType]TariffRule{
+			models.Truck: {HourlyRate: &truckRate},
+		},
+		SlotCategories: map[models.LotCategory]TariffRule{
+			models.ChargingLot: {HourlyRate: &chargingRate},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Error should be empty")
+	}
+	return tariff
+}
+
+func TestNewTariffWithInputError(t *testing.T) {
+	hourlyRate := -1.0
+	tariff, err := NewTariff(TariffConfig{TariffRule: TariffRule{HourlyRate: &hourlyRate}})
+	if tariff != nil {
+		t.Errorf("Tariff should be empty")
+	}
+	if !(err != nil && err.Error() == errmsgs.TariffConfigInvalidError().Error()) {
+		t.Errorf("Error should have and should be tariff config is invalid")
+	}
+
+	tariff, err = NewTariff(TariffConfig{
+		VehicleTypes: map[models.VehicleType]TariffRule{
+			models.Car: {NightRate: &NightRate{From: "25:00", To: "06:00"}},
+		},
+	})
+	if tariff != nil {
+		t.Errorf("Tariff should be empty")
+	}
+	if !(err != nil && err.Error() == errmsgs.TariffConfigInvalidError().Error()) {
+		t.Errorf("Error should have and should be tariff config is invalid")
+	}
+}
+
+func TestTariffFeeWithRules(t *testing.T) {
+	tariff := newTestTariff(t)
+	car := NewCar("plate-1", "red", 1)
+	truck, _ := NewVehicle(models.Truck, "truck-1", "red", VehicleAttributes{})
+	morning := time.Date(2020, 9, 28, 8, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name      string
+		vehicle   IVehicle
+		category  models.LotCategory
+		entryTime time.Time
+		duration  time.Duration
+		fee       float64
+	}{
+		{name: "free minutes", vehicle: car, category: models.CompactLot, entryTime: morning, duration: 15 * time.Minute, fee: 0},
+		{name: "started hour", vehicle: car, category: models.CompactLot, entryTime: morning, duration: 16 * time.Minute, fee: 20},
+		{name: "hours", vehicle: car, category: models.CompactLot, entryTime: morning, duration: 2*time.Hour + 15*time.Minute, fee: 40},
+		{name: "daily cap", vehicle: car, category: models.CompactLot, entryTime: morning, duration: 10 * time.Hour, fee: 100},
+		{name: "two days", vehicle: car, category: models.CompactLot, entryTime: morning, duration: 26*time.Hour + 15*time.Minute, fee: 140},
+		{name: "night rate", vehicle: car, category: models.CompactLot, entryTime: morning.Add(14 * time.Hour), duration: 3*time.Hour + 15*time.Minute, fee: 30},
+		{name: "vehicle type", vehicle: truck, category: models.CompactLot, entryTime: morning, duration: 1*time.Hour + 15*time.Minute, fee: 40},
+		{name: "slot category", vehicle: truck, category: models.ChargingLot, entryTime: morning, duration: 1*time.Hour + 15*time.Minute, fee: 30},
+	}
+	for _, testCase := range testCases {
+		fee := tariff.Fee(testCase.vehicle, testCase.category, testCase.entryTime, testCase.entryTime.Add(testCase.duration))
+		if fee != testCase.fee {
+			t.Errorf("Fee of %s should be %.2f but %.2f", testCase.name, testCase.fee, fee)
+		}
+	}
+}
+
+func TestLoadTariffWithConfigFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tariffs")
+	if err != nil {
+		t.Fatalf("Error should be empty")
+	}
+	defer os.RemoveAll(dir)
+
+	tariff, err := LoadTariff(filepath.Join(dir, "not-found.json"))
+	if tariff != nil || err == nil {
+		t.Errorf("Tariff should not be loaded")
+	}
+
+	invalidPath := filepath.Join(dir, "invalid.json")
+	ioutil.WriteFile(invalidPath, []byte("{hourly_rate"), 0644)
+	tariff, err = LoadTariff(invalidPath)
+	if tariff != nil || !(err != nil && err.Error() == errmsgs.TariffConfigInvalidError().Error()) {
+		t.Errorf("Error should have and should be tariff config is invalid")
+	}
+
+	validPath := filepath.Join(dir, "tariffs.json")
+	ioutil.WriteFile(validPath, []byte(`{"hourly_rate": 20, "vehicle_types": {"truck": {"hourly_rate": 40}}}`), 0644)
+	tariff, err = LoadTariff(validPath)
+	if tariff == nil || err != nil {
+		t.Fatalf("Tariff should be loaded")
+	}
+	truck, _ := NewVehicle(models.Truck, "truck-1", "red", VehicleAttributes{})
+	entryTime := time.Date(2020, 9, 28, 8, 0, 0, 0, time.UTC)
+	if fee := tariff.Fee(truck, models.CompactLot, entryTime, entryTime.Add(time.Hour)); fee != 40 {
+		t.Errorf("Fee should be 40 but %.2f", fee)
+	}
+}
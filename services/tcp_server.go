@@ -0,0 +1,75 @@
+package services
+
+import (
+	"net"
+	"parkinglot/models"
+)
+
+// defaultTCPAddress is address that TCP mode listen when address is not set
+const defaultTCPAddress = ":9090"
+
+// TCPServer is line protocol server that run text commands of each connection
+// All connections share parking lots, each connection select its own parking lot
+type TCPServer struct {
+	parkingSitesSvc IParkingSites
+	// journal keep commands of all connections, nil is no journal
+	journal *CommandJournal
+	// output is format of command responses of all connections
+	output models.OutputFormats
+}
+
+// NewTCPServer is a new line protocol server of parking sites
+func NewTCPServer(parkingSitesSvc IParkingSites) *TCPServer {
+	return &TCPServer{parkingSitesSvc: parkingSitesSvc}
+}
+
+// SetJournal is set journal that commands of all connections are written to
+func (svc *TCPServer) SetJournal(journal *CommandJournal) {
+	svc.journal = journal
+}
+
+// SetOutput is set format of command responses of all connections
+func (svc *TCPServer) SetOutput(output models.OutputFormats) {
+	svc.output = output
+}
+
+// ListenAndServe is listen at address and serve connections
+func (svc *TCPServer) ListenAndServe(address string) error {
+	if address == "" {
+		address = defaultTCPAddress
+	}
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	return svc.Serve(listener)
+}
+
+// Serve is accept connections of listener until listener is closed
+func (svc *TCPServer) Serve(listener net.Listener) error {
+	defer listener.Close()
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		go svc.handleConn(conn)
+	}
+}
+
+// handleConn is run commands of connection, default parking lot is selected when connect
+// Commands that read or write files of server are not allowed
+// Connection is closed when client close it or send exit command
+func (svc *TCPServer) handleConn(conn net.Conn) {
+	defer conn.Close()
+
+	parkingLotSvc, err := svc.parkingSitesSvc.Get(defaultParkingSiteName)
+	if err != nil {
+		parkingLotSvc = nil
+	}
+	parkingCommand := newParkingLotCommandInput(conn, conn, svc.parkingSitesSvc, parkingLotSvc)
+	parkingCommand.journal = svc.journal
+	parkingCommand.isFileCommandDisabled = true
+	parkingCommand.output = svc.output
+	parkingCommand.start()
+}
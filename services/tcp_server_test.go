@@ -0,0 +1,132 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// testTCPServerHelper is TCP server with default parking lot at random local port
+func testTCPServerHelper(t *testing.T) (IParkingSites, string, func()) {
+	parkingSites := NewParkingSitesWithClock(newMockClock())
+	parkingSites.Create(defaultParkingSiteName)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listener should be created (%s)", err.Error())
+	}
+	go NewTCPServer(parkingSites).Serve(listener)
+	return parkingSites, listener.Addr().String(), func() { listener.Close() }
+}
+
+// testTCPCommandsHelper is send commands to server and read responses until connection is closed
+func testTCPCommandsHelper(address, commands string, t *testing.T) string {
+	conn, err := net.Dial("tcp", address)
+	if err != nil {
+		t.Errorf("Connection should be connected (%s)", err.Error())
+		return ""
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte(commands + "exit\n")); err != nil {
+		t.Errorf("Commands should be sent (%s)", err.Error())
+		return ""
+	}
+	responses, err := ioutil.ReadAll(conn)
+	if err != nil {
+		t.Errorf("Responses should be read (%s)", err.Error())
+	}
+	return string(responses)
+}
+
+func TestTCPServerWithSameResponsesAsCommandInput(t *testing.T) {
+	commands := strings.Join([]string{
+		"create_parking_lot 3",
+		"park KA-01-HH-1234 White",
+		"park KA-01-HH-9999 White truck",
+		"park KA-01-BB-0001 Black",
+		"status",
+		"leave 1",
+		"slot_numbers_for_cars_with_colour White",
+		"create_lot mall",
+		"lots",
+		"unknown_command",
+	}, "\r\n") + "\r\n"
+
+	expected := &bytes.Buffer{}
+	parkingSites := NewParkingSitesWithClock(newMockClock())
+	parkingLot, _ := parkingSites.Create(defaultParkingSiteName)
+	newParkingLotCommandInput(strings.NewReader(commands), expected, parkingSites, parkingLot).start()
+
+	_, address, closeServer := testTCPServerHelper(t)
+	defer closeServer()
+
+	responses := testTCPCommandsHelper(address, commands, t)
+	if responses != expected.String() {
+		t.Errorf("Responses should be same as command input\n%s\nnot\n%s", expected.String(), responses)
+	}
+}
+
+func TestTCPServerWithConcurrentConnections(t *testing.T) {
+	parkingSites, address, closeServer := testTCPServerHelper(t)
+	defer closeServer()
+	testTCPCommandsHelper(address, "create_parking_lot 10\n", t)
+
+	var (
+		wg        sync.WaitGroup
+		mutex     sync.Mutex
+		responses = map[string]bool{}
+	)
+	for worker := 0; worker < 15; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			response := testTCPCommandsHelper(address, fmt.Sprintf("park plate-%d red\n", worker), t)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			if response != "Sorry, parking lot is full\n" && responses[response] {
+				t.Errorf("Response %s should be once", response)
+			}
+			responses[response] = true
+		}(worker)
+	}
+	wg.Wait()
+
+	for lotNo := 1; lotNo <= 10; lotNo++ {
+		if !responses[fmt.Sprintf("Allocated slot number: %d\n", lotNo)] {
+			t.Errorf("Slot number %d should be allocated", lotNo)
+		}
+	}
+	parkingLot, _ := parkingSites.Get(defaultParkingSiteName)
+	if len(parkingLot.GetAllAvailableLotNos()) != 0 {
+		t.Errorf("All available lot should be empty")
+	}
+}
+
+func TestTCPServerWithFileCommandsNotAllowed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tcp")
+	if err != nil {
+		t.Fatalf("Error should be empty")
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "parking.json")
+
+	_, address, closeServer := testTCPServerHelper(t)
+	defer closeServer()
+
+	responses := testTCPCommandsHelper(address, fmt.Sprintf("save %s\nload %s\nload_tariff %s\n", path, path, path), t)
+	expected := strings.Repeat("Command is not allowed over network connection\n", 3)
+	if responses != expected {
+		t.Errorf("Responses should be\n%s\nnot\n%s", expected, responses)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Snapshot file should not be written")
+	}
+}
@@ -1,32 +1,162 @@
 package services
 
+import (
+	"parkinglot/errmsgs"
+	"parkinglot/models"
+)
+
+// vehicleTypeUsageLots is default usage lot of each vehicle type
+var vehicleTypeUsageLots = map[models.VehicleType]float32{
+	models.Motorcycle:      0.5,
+	models.Car:             1,
+	models.Truck:           2,
+	models.Bus:             3,
+	models.ElectricVehicle: 1,
+	models.Accessible:      1,
+}
+
 // IVehicle is vehicle interface
 type IVehicle interface {
 	PlateNumber() string
 	Color() string
 	UsageLot() float32
+	Type() models.VehicleType
 }
 
-// carBuilder is one of vehicle
-type carBuilder struct {
+// IChargeableVehicle is vehicle that can charge at lot
+// Vehicle that need charging park at charging lot first
+type IChargeableVehicle interface {
+	IVehicle
+	NeedCharging() bool
+}
+
+// IPermitVehicle is vehicle that has accessible permit
+type IPermitVehicle interface {
+	IVehicle
+	PermitNo() string
+}
+
+// VehicleAttributes is optional attributes for build vehicle
+type VehicleAttributes struct {
+	// UsageLot zero value is use default usage lot of vehicle type
+	UsageLot float32
+	PermitNo string
+}
+
+// NewVehicle is a new vehicle by vehicle type
+func NewVehicle(vehicleType models.VehicleType, plateNumber, color string, attrs VehicleAttributes) (IVehicle, error) {
+	usage, ok := vehicleTypeUsageLots[vehicleType]
+	if !ok {
+		return nil, errmsgs.VehicleTypeInvalidError()
+	}
+	if attrs.UsageLot > 0 {
+		usage = attrs.UsageLot
+	}
+
+	base := vehicleBuilder{plateNumber: plateNumber, color: color, usageLot: usage}
+	switch vehicleType {
+	case models.Motorcycle:
+		return &motorcycleBuilder{vehicleBuilder: base}, nil
+	case models.Truck:
+		return &truckBuilder{vehicleBuilder: base}, nil
+	case models.Bus:
+		return &busBuilder{vehicleBuilder: base}, nil
+	case models.ElectricVehicle:
+		return &electricVehicleBuilder{vehicleBuilder: base, needCharging: true}, nil
+	case models.Accessible:
+		if attrs.PermitNo == "" {
+			return nil, errmsgs.VehiclePermitRequiredError()
+		}
+		return &accessibleBuilder{vehicleBuilder: base, permitNo: attrs.PermitNo}, nil
+	default:
+		return &carBuilder{vehicleBuilder: base}, nil
+	}
+}
+
+// vehicleBuilder is common data of all vehicles
+type vehicleBuilder struct {
 	plateNumber string
 	color       string
 	usageLot    float32
 }
 
+func (svc *vehicleBuilder) PlateNumber() string {
+	return svc.plateNumber
+}
+
+func (svc *vehicleBuilder) Color() string {
+	return svc.color
+}
+
+func (svc *vehicleBuilder) UsageLot() float32 {
+	return svc.usageLot
+}
+
+// carBuilder is one of vehicle
+type carBuilder struct {
+	vehicleBuilder
+}
+
 // NewCar is a new struct car
 func NewCar(plateNumber, color string, usage float32) IVehicle {
-	return &carBuilder{plateNumber: plateNumber, color: color, usageLot: usage}
+	return &carBuilder{vehicleBuilder{plateNumber: plateNumber, color: color, usageLot: usage}}
 }
 
-func (svc *carBuilder) PlateNumber() string {
-	return svc.plateNumber
+func (svc *carBuilder) Type() models.VehicleType {
+	return models.Car
 }
 
-func (svc *carBuilder) Color() string {
-	return svc.color
+// motorcycleBuilder is small vehicle that can share lot
+type motorcycleBuilder struct {
+	vehicleBuilder
 }
 
-func (svc *carBuilder) UsageLot() float32 {
-	return svc.usageLot
+func (svc *motorcycleBuilder) Type() models.VehicleType {
+	return models.Motorcycle
+}
+
+// truckBuilder is big vehicle that use adjacent lots
+type truckBuilder struct {
+	vehicleBuilder
+}
+
+func (svc *truckBuilder) Type() models.VehicleType {
+	return models.Truck
+}
+
+// busBuilder is biggest vehicle that use adjacent lots
+type busBuilder struct {
+	vehicleBuilder
+}
+
+func (svc *busBuilder) Type() models.VehicleType {
+	return models.Bus
+}
+
+// electricVehicleBuilder is car that need charging
+type electricVehicleBuilder struct {
+	vehicleBuilder
+	needCharging bool
+}
+
+func (svc *electricVehicleBuilder) Type() models.VehicleType {
+	return models.ElectricVehicle
+}
+
+func (svc *electricVehicleBuilder) NeedCharging() bool {
+	return svc.needCharging
+}
+
+// accessibleBuilder is car that has accessible permit
+type accessibleBuilder struct {
+	vehicleBuilder
+	permitNo string
+}
+
+func (svc *accessibleBuilder) Type() models.VehicleType {
+	return models.Accessible
+}
+
+func (svc *accessibleBuilder) PermitNo() string {
+	return svc.permitNo
 }